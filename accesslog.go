@@ -0,0 +1,152 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Formats of access log.
+const (
+	LogJSON     = "json"     // One JSON object per line
+	LogCommon   = "common"   // The Common Log Format of Apache
+	LogCombined = "combined" // The Combined Log Format of Apache
+)
+
+// The time layout of Common Log Format.
+const clfTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// AccessLogConfig is the config for AccessLog middleware.
+type AccessLogConfig struct {
+	Output io.Writer // The log output, default is os.Stdout
+	Format string    // The log format, default is LogJSON
+
+	// The rate of logged requests, between 0 and 1. Default 0 logs all.
+	// The requests with 5xx status are always logged.
+	SampleRate float64
+
+	// Skip the requests matching the route patterns, such as "/health".
+	SkipRoutes []string
+
+	// Skip the requests if it returns true, it is called after the handlers.
+	Skip func(c *Context) bool
+}
+
+// Defined for an access log entry.
+type accessEntry struct {
+	Time      time.Time `json:"time"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Route     string    `json:"route"`
+	Status    int       `json:"status"`
+	Bytes     int       `json:"bytes"`
+	Latency   float64   `json:"latency_ms"`
+	ClientIP  string    `json:"client_ip"`
+	RequestID string    `json:"request_id,omitempty"`
+	Referer   string    `json:"-"`
+	UserAgent string    `json:"-"`
+	Proto     string    `json:"-"`
+	Uri       string    `json:"-"`
+}
+
+// AccessLog logs the requests after they are handled.
+// The entry includes the method, the raw path, the matched route pattern,
+// the status, the body bytes, the latency, the client IP and the request ID.
+// The route pattern keeps the logs aggregatable, such as "/users/(id)".
+func AccessLog(config AccessLogConfig) HandlerFunc {
+	out := config.Output
+	if out == nil {
+		out = os.Stdout
+	}
+	skipRoutes := make(map[string]bool, len(config.SkipRoutes))
+	for _, route := range config.SkipRoutes {
+		skipRoutes[route] = true
+	}
+	var mu sync.Mutex
+
+	return func(c *Context) {
+		start := time.Now()
+		c.Next()
+
+		route := ""
+		if c.Result != nil && c.Result.IsMatch {
+			route = c.Result.Url
+		}
+		if skipRoutes[route] && route != "" {
+			return
+		}
+		status := c.Response.Status()
+		if config.SampleRate > 0 && status < http.StatusInternalServerError &&
+			rand.Float64() >= config.SampleRate {
+			return
+		}
+		if config.Skip != nil && config.Skip(c) {
+			return
+		}
+
+		r := c.Request
+		entry := &accessEntry{
+			Time:      start,
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			Route:     route,
+			Status:    status,
+			Bytes:     c.Response.Size(),
+			Latency:   float64(time.Since(start)) / float64(time.Millisecond),
+			ClientIP:  c.ClientIP(),
+			RequestID: c.RequestID(),
+			Referer:   r.Referer(),
+			UserAgent: r.UserAgent(),
+			Proto:     r.Proto,
+			Uri:       c.maskURI(r.RequestURI),
+		}
+
+		line := entry.format(config.Format)
+		mu.Lock()
+		out.Write(line)
+		mu.Unlock()
+	}
+}
+
+// Format the entry as a log line.
+func (e *accessEntry) format(format string) []byte {
+	switch format {
+	case LogCommon, LogCombined:
+		uri := e.Uri
+		if uri == "" {
+			uri = e.Path
+		}
+		size := "-"
+		if e.Bytes > 0 {
+			size = strconv.Itoa(e.Bytes)
+		}
+
+		line := fmt.Sprintf(`%s - - [%s] "%s %s %s" %d %s`, e.ClientIP,
+			e.Time.Format(clfTimeLayout), e.Method, uri, e.Proto, e.Status, size)
+		if format == LogCombined {
+			line += fmt.Sprintf(` %q %q`, dash(e.Referer), dash(e.UserAgent))
+		}
+		return []byte(line + "\n")
+	default:
+		data, _ := json.Marshal(e)
+		return append(data, '\n')
+	}
+}
+
+// Get "-" for the empty log field.
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,110 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func accessLogMux(config AccessLogConfig) *Mux {
+	mux := NewMux("myMux")
+	mux.Use(RequestID(), AccessLog(config))
+	mux.Add([]string{"GET"}, "/users/(id)", func(c *Context) {
+		c.Text(http.StatusOK, "user")
+	})
+	mux.Add([]string{"GET"}, "/health", func(c *Context) {})
+	mux.Add([]string{"GET"}, "/fail", func(c *Context) {
+		c.Status(http.StatusBadGateway)
+	})
+	mux.Start()
+	return mux
+}
+
+func TestAccessLogJSON(t *testing.T) {
+	var out bytes.Buffer
+	mux := accessLogMux(AccessLogConfig{Output: &out})
+
+	w := serve(mux, "GET", "/users/12?x=1", "X-Forwarded-For", "10.0.0.1, 10.0.0.2", "X-Request-Id", "req-1")
+	assertTrue(w.Header().Get(HeaderRequestID) == "req-1", "case1", t)
+
+	var entry map[string]interface{}
+	assertTrue(json.Unmarshal(out.Bytes(), &entry) == nil, "case1", t)
+	assertTrue(entry["method"] == "GET" && entry["path"] == "/users/12", "case2", t)
+	assertTrue(entry["route"] == "/users/(id)" && entry["status"] == float64(200), "case2", t)
+	assertTrue(entry["bytes"] == float64(4) && entry["client_ip"] == "10.0.0.1", "case2", t)
+	assertTrue(entry["request_id"] == "req-1", "case2", t)
+	_, ok := entry["latency_ms"]
+	assertTrue(ok, "case2", t)
+
+	out.Reset()
+	serve(mux, "GET", "/none")
+	json.Unmarshal(out.Bytes(), &entry)
+	assertTrue(entry["route"] == "" && entry["status"] == float64(404), "case3", t)
+	assertTrue(len(entry["request_id"].(string)) == 32, "case3", t)
+}
+
+func TestAccessLogCombined(t *testing.T) {
+	var out bytes.Buffer
+	mux := accessLogMux(AccessLogConfig{Output: &out, Format: LogCombined})
+
+	serve(mux, "GET", "/users/12?x=1", "User-Agent", "curl/7.0")
+	line := out.String()
+	regex := regexp.MustCompile(`^192\.0\.2\.1 - - \[[^\]]+\] "GET /users/12\?x=1 HTTP/1\.1" 200 4 "-" "curl/7\.0"\n$`)
+	assertTrue(regex.MatchString(line), "case1", t)
+
+	out.Reset()
+	mux = accessLogMux(AccessLogConfig{Output: &out, Format: LogCommon})
+	serve(mux, "GET", "/health")
+	assertTrue(strings.HasSuffix(out.String(), `"GET /health HTTP/1.1" 200 -`+"\n"), "case2", t)
+
+	// the query param of API key is masked
+	out.Reset()
+	mux = NewMux("myMux")
+	mux.Use(AccessLog(AccessLogConfig{Output: &out, Format: LogCommon}),
+		APIKeyAuth(APIKeyConfig{Query: "api_key", Verify: func(key string) *Principal {
+			return &Principal{}
+		}}))
+	mux.Add([]string{"GET"}, "/users", func(c *Context) {})
+	mux.Start()
+	serve(mux, "GET", "/users?api_key=secret&x=1")
+	assertTrue(strings.Contains(out.String(), `"GET /users?api_key=******&x=1 HTTP/1.1"`), "case3", t)
+}
+
+func TestAccessLogSkip(t *testing.T) {
+	var out bytes.Buffer
+	mux := accessLogMux(AccessLogConfig{
+		Output:     &out,
+		SkipRoutes: []string{"/health"},
+		Skip: func(c *Context) bool {
+			return c.Param("id") == "0"
+		},
+	})
+
+	serve(mux, "GET", "/health")
+	serve(mux, "GET", "/users/0")
+	assertTrue(out.Len() == 0, "case1", t)
+
+	serve(mux, "GET", "/users/1")
+	assertTrue(strings.Count(out.String(), "\n") == 1, "case2", t)
+}
+
+func TestAccessLogSample(t *testing.T) {
+	var out bytes.Buffer
+	mux := accessLogMux(AccessLogConfig{Output: &out, SampleRate: 0.000001})
+
+	for i := 0; i < 10; i++ {
+		serve(mux, "GET", "/users/1")
+	}
+	assertTrue(out.Len() == 0, "case1", t)
+
+	// errors are always logged
+	serve(mux, "GET", "/fail")
+	assertTrue(strings.Count(out.String(), "\n") == 1, "case2", t)
+}
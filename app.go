@@ -0,0 +1,286 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"context"
+	"github.com/arging/utils/errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// The default timeout of graceful shutdown.
+const DefaultShutdownTimeout = 30 * time.Second
+
+// App is the light application, it ties the Mux, the global middleware and
+// the http.Server together, and manages the server lifecycle:
+//
+//	app := light.NewApp("myApp")
+//	app.Use(light.Recovery())
+//	app.Add([]string{"GET"}, "/users/(id)", showUser)
+//	app.OnShutdown(func(ctx context.Context) error {
+//		return db.Close()
+//	})
+//	if err := app.Run(":8080"); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Run blocks until the app is shut down. On SIGINT or SIGTERM, the app stops
+// accepting connections and waits for the in-flight requests to finish.
+type App struct {
+	*Mux
+
+	// Server serves the app, its Handler is the Mux if it is nil.
+	// Set its timeouts before running the app.
+	Server *http.Server
+
+	// The timeout of graceful shutdown on signal, default is 30 seconds.
+	ShutdownTimeout time.Duration
+
+	// Mode is the run mode, such as development and production, it is applied
+	// when the app starts. Default is the environment variable LIGHT_MODE,
+	// or production if it is empty. The short names "dev" and "prod" are
+	// also accepted.
+	Mode string
+
+	// Config of the app, the "server" section configures the Server when the
+	// app starts, see ServerConfig. Its profile is the environment variable
+	// LIGHT_PROFILE, or the profile of the mode from LIGHT_MODE, such as
+	// "prod" for production.
+	Config *Config
+
+	startHooks    []func(app *App) error
+	shutdownHooks []func(ctx context.Context) error
+
+	startOnce    sync.Once
+	startErr     errors.Error
+	shutdownOnce sync.Once
+	shutdownErr  errors.Error
+	stopped      chan struct{}
+}
+
+// Create the app by name, the name is also the name of its Router.
+func NewApp(name string) *App {
+	mode := os.Getenv(EnvMode)
+	profile := os.Getenv(EnvProfile)
+	if profile == "" {
+		profile = modeProfile(mode)
+	}
+	return &App{
+		Mux:             NewMux(name),
+		Server:          &http.Server{},
+		ShutdownTimeout: DefaultShutdownTimeout,
+		Mode:            mode,
+		Config:          NewConfig(profile),
+		stopped:         make(chan struct{}),
+	}
+}
+
+// Add the hook called when the app starts, after the Mux is started and
+// before the server accepts connections. If a hook fails, the app doesn't run.
+func (a *App) OnStart(hook func(app *App) error) {
+	a.startHooks = append(a.startHooks, hook)
+}
+
+// Add the hook called when the app shuts down, after the in-flight requests
+// are finished. The hooks are called in reverse order of adding, and all of
+// them are called even if some fail.
+func (a *App) OnShutdown(hook func(ctx context.Context) error) {
+	a.shutdownHooks = append(a.shutdownHooks, hook)
+}
+
+// Start the Mux and call the start hooks. It is called by Run, and only the
+// first call takes effect. Call it before serving the app by other servers.
+// The run mode is applied before the Mux starts, and the route table is
+// printed in development mode.
+func (a *App) Start() errors.Error {
+	a.startOnce.Do(func() {
+		mode := parseMode(a.Mode)
+		if mode == "" {
+			a.startErr = errors.Newf("app mode error, unknown mode %q: %s.", a.Mode, a.router.Name())
+			return
+		}
+		a.Mode = mode
+		a.applyMode()
+
+		if err := a.configure(); err != nil {
+			a.startErr = errors.Wrapf(err, "app config error: %s.", a.router.Name())
+			return
+		}
+		if err := a.Mux.Start(); err != nil {
+			a.startErr = errors.Wrapf(err, "app start error: %s.", a.router.Name())
+			return
+		}
+		if a.Mode == ModeDevelopment {
+			a.printRoutes()
+		}
+		for _, hook := range a.startHooks {
+			if err := hook(a); err != nil {
+				a.startErr = errors.Wrapf(err, "app start hook error: %s.", a.router.Name())
+				return
+			}
+		}
+	})
+	return a.startErr
+}
+
+// ServerConfig is the "server" section of the app config, such as:
+//
+//	[server]
+//	addr = :8080
+//	read_timeout = 10s
+//	shutdown_timeout = 1m
+//
+// The unset keys leave the Server unchanged.
+type ServerConfig struct {
+	Addr              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration
+}
+
+// Configure the Server by the "server" section of the config.
+func (a *App) configure() errors.Error {
+	var sc ServerConfig
+	if err := a.Config.Bind("server", &sc); err != nil {
+		return err
+	}
+	if sc.Addr != "" {
+		a.Server.Addr = sc.Addr
+	}
+	if sc.ReadTimeout != 0 {
+		a.Server.ReadTimeout = sc.ReadTimeout
+	}
+	if sc.ReadHeaderTimeout != 0 {
+		a.Server.ReadHeaderTimeout = sc.ReadHeaderTimeout
+	}
+	if sc.WriteTimeout != 0 {
+		a.Server.WriteTimeout = sc.WriteTimeout
+	}
+	if sc.IdleTimeout != 0 {
+		a.Server.IdleTimeout = sc.IdleTimeout
+	}
+	if sc.ShutdownTimeout != 0 {
+		a.ShutdownTimeout = sc.ShutdownTimeout
+	}
+	return nil
+}
+
+// Run the app on the TCP address, such as ":8080".
+// If the addr is empty, the Addr of the Server is used, it can be configured
+// by the "server.addr" key of the config.
+// It blocks until the app is shut down, and returns nil if it is graceful.
+func (a *App) Run(addr string) errors.Error {
+	// Start first, so the addr can be configured.
+	if err := a.Start(); err != nil {
+		return err
+	}
+	l, err := a.listen(addr)
+	if err != nil {
+		return err
+	}
+	return a.serve(l, func() error {
+		return a.Server.Serve(l)
+	})
+}
+
+// Run the app on the TCP address with HTTPS, see Run.
+func (a *App) RunTLS(addr string, certFile string, keyFile string) errors.Error {
+	// Start first, so the addr can be configured.
+	if err := a.Start(); err != nil {
+		return err
+	}
+	l, err := a.listen(addr)
+	if err != nil {
+		return err
+	}
+	return a.serve(l, func() error {
+		return a.Server.ServeTLS(l, certFile, keyFile)
+	})
+}
+
+// Run the app on the listener, see Run.
+func (a *App) Serve(l net.Listener) errors.Error {
+	return a.serve(l, func() error {
+		return a.Server.Serve(l)
+	})
+}
+
+// Shut down the app gracefully. The server stops accepting connections, and
+// waits for the in-flight requests until the context is done. Then the
+// shutdown hooks are called. Only the first call takes effect, and the other
+// calls wait for it.
+func (a *App) Shutdown(ctx context.Context) errors.Error {
+	a.shutdownOnce.Do(func() {
+		defer close(a.stopped)
+		if err := a.Server.Shutdown(ctx); err != nil {
+			a.shutdownErr = errors.Wrapf(err, "app shutdown error: %s.", a.router.Name())
+		}
+		for i := len(a.shutdownHooks) - 1; i >= 0; i-- {
+			if err := a.shutdownHooks[i](ctx); err != nil && a.shutdownErr == nil {
+				a.shutdownErr = errors.Wrapf(err, "app shutdown hook error: %s.", a.router.Name())
+			}
+		}
+	})
+	return a.shutdownErr
+}
+
+// Listen on the TCP address.
+func (a *App) listen(addr string) (net.Listener, errors.Error) {
+	if addr == "" {
+		addr = a.Server.Addr
+	}
+	if addr == "" {
+		addr = ":http"
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "app listen error: %s.", addr)
+	}
+	return l, nil
+}
+
+// Start the app and serve the listener, until the app is shut down.
+func (a *App) serve(l net.Listener, serve func() error) errors.Error {
+	if err := a.Start(); err != nil {
+		l.Close()
+		return err
+	}
+	if a.Server.Handler == nil {
+		a.Server.Handler = a.Mux
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case sig := <-signals:
+			a.Logger.Printf("%s received, shutting down: %s", sig, a.router.Name())
+			ctx, cancel := context.WithTimeout(context.Background(), a.ShutdownTimeout)
+			defer cancel()
+			a.Shutdown(ctx)
+		case <-done:
+		}
+	}()
+
+	a.Logger.Printf("listening on %s: %s", l.Addr(), a.router.Name())
+	if err := serve(); err != http.ErrServerClosed {
+		return errors.Wrapf(err, "app serve error: %s.", a.router.Name())
+	}
+	// Serve returns once shutdown begins, wait for the requests to finish.
+	<-a.stopped
+	return a.shutdownErr
+}
@@ -0,0 +1,151 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// Create an app serving on a random local port, the slow handler waits for
+// the release channel. The setup is called before serving.
+func testApp(t *testing.T, release chan struct{}, setup func(app *App)) (*App, net.Listener, chan error) {
+	app := NewApp("myApp")
+	app.Logger.SetOutput(io.Discard)
+	app.Add([]string{"GET"}, "/slow", func(c *Context) {
+		<-release
+		c.Text(http.StatusOK, "done")
+	})
+	app.Add([]string{"GET"}, "/fast", urlHandler)
+	setup(app)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := make(chan error, 1)
+	go func() {
+		if err := app.Serve(l); err != nil {
+			errs <- err
+		}
+		close(errs)
+	}()
+	return app, l, errs
+}
+
+func get(url string) (string, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
+
+// Wait until the app accepts requests.
+func waitApp(t *testing.T, l net.Listener) {
+	for i := 0; i < 100; i++ {
+		if _, err := get("http://" + l.Addr().String() + "/fast"); err == nil {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("app not started")
+}
+
+func TestAppShutdown(t *testing.T) {
+	release := make(chan struct{})
+	var hooks []string
+	app, l, errs := testApp(t, release, func(app *App) {
+		app.OnStart(func(app *App) error {
+			hooks = append(hooks, "start")
+			return nil
+		})
+		for i := 1; i <= 2; i++ {
+			i := i
+			app.OnShutdown(func(ctx context.Context) error {
+				hooks = append(hooks, fmt.Sprint("shutdown", i))
+				return nil
+			})
+		}
+	})
+	waitApp(t, l)
+	assertTrue(len(hooks) == 1 && hooks[0] == "start", "case1", t)
+
+	// the in-flight request is drained
+	bodies := make(chan string)
+	go func() {
+		body, _ := get("http://" + l.Addr().String() + "/slow")
+		bodies <- body
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	stopped := make(chan error)
+	go func() {
+		stopped <- app.Shutdown(context.Background())
+	}()
+	time.Sleep(50 * time.Millisecond)
+	_, err := get("http://" + l.Addr().String() + "/fast")
+	assertTrue(err != nil, "case2", t)
+
+	close(release)
+	assertTrue(<-bodies == "done", "case3", t)
+	assertTrue(<-stopped == nil && <-errs == nil, "case3", t)
+	assertTrue(len(hooks) == 3 && hooks[1] == "shutdown2" && hooks[2] == "shutdown1", "case4", t)
+}
+
+func TestAppSignal(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	shutdown := make(chan bool, 1)
+	_, l, errs := testApp(t, release, func(app *App) {
+		app.OnShutdown(func(ctx context.Context) error {
+			shutdown <- true
+			return nil
+		})
+	})
+	waitApp(t, l)
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+	select {
+	case err := <-errs:
+		assertTrue(err == nil && <-shutdown, "case1", t)
+	case <-time.After(5 * time.Second):
+		t.Fatal("app not shut down")
+	}
+}
+
+func TestAppError(t *testing.T) {
+	app := NewApp("myApp")
+	app.OnStart(func(app *App) error {
+		return fmt.Errorf("no database")
+	})
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	assertTrue(app.Serve(l) != nil, "case1", t)
+	// the listener is closed
+	_, err := net.Dial("tcp", l.Addr().String())
+	assertTrue(err != nil, "case1", t)
+
+	app = NewApp("myApp")
+	app.Add([]string{"GET"}, "/a", urlHandler)
+	app.Add([]string{"GET"}, "/a", urlHandler)
+	assertTrue(app.Run("127.0.0.1:0") != nil, "case2", t)
+
+	app = NewApp("myApp")
+	assertTrue(app.Run("127.0.0.1:-1") != nil, "case3", t)
+
+	app = NewApp("myApp")
+	app.OnShutdown(func(ctx context.Context) error {
+		return fmt.Errorf("close error")
+	})
+	assertTrue(app.Shutdown(context.Background()) != nil, "case4", t)
+}
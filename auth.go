@@ -0,0 +1,225 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"crypto/rsa"
+	"github.com/uestcer/light/jwt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The metadata key of public routes, the auth middleware skips them:
+//
+//	public := mux.Group("/public").Meta(light.MetaAuthSkip, true)
+//	mux.Add([]string{"GET"}, "/health", health).Meta(light.MetaAuthSkip, true)
+const MetaAuthSkip = "auth.skip"
+
+// The auth schemes of Principal.
+const (
+	SchemeBasic  = "basic"
+	SchemeAPIKey = "apikey"
+	SchemeJWT    = "jwt"
+)
+
+// The context key of principal.
+const principalKey = "light.principal"
+
+// Principal is the authenticated client of the request.
+type Principal struct {
+	ID          string     // The user name, the key owner or the token subject
+	Scheme      string     // The auth scheme, such as "basic" and "jwt"
+	Roles       []string   // The roles of the principal
+	Permissions []string   // The permissions of the principal
+	Claims      jwt.Claims // The token claims for JWT
+}
+
+// BasicAuthConfig is the config for BasicAuth middleware.
+type BasicAuthConfig struct {
+	Realm string // The realm of the challenge, default is "Restricted"
+
+	// Verify the user and password. Return nil, when they are wrong.
+	// The password should be compared in constant time.
+	Verify func(user string, password string) *Principal
+}
+
+// BasicAuth authenticates the requests by HTTP Basic, see RFC 7617.
+// The routes with metadata MetaAuthSkip are skipped.
+func BasicAuth(config BasicAuthConfig) HandlerFunc {
+	if config.Realm == "" {
+		config.Realm = "Restricted"
+	}
+	challenge := "Basic realm=" + strconv.Quote(config.Realm) + `, charset="UTF-8"`
+
+	return func(c *Context) {
+		if c.skipAuth() {
+			return
+		}
+		user, password, ok := c.Request.BasicAuth()
+		var p *Principal
+		if ok {
+			p = config.Verify(user, password)
+		}
+		if p == nil {
+			c.unauthorized(challenge, "invalid user or password")
+			return
+		}
+		// Copy it, the verifier may share the principal.
+		principal := *p
+		if principal.ID == "" {
+			principal.ID = user
+		}
+		principal.Scheme = SchemeBasic
+		c.Set(principalKey, &principal)
+	}
+}
+
+// APIKeyConfig is the config for APIKeyAuth middleware.
+type APIKeyConfig struct {
+	Header string // The header of the key, default is "X-API-Key"
+	Query  string // The query param of the key, empty to disable
+
+	// Verify the key. Return nil, when the key is wrong.
+	Verify func(key string) *Principal
+}
+
+// APIKeyAuth authenticates the requests by the API key in the header, or in
+// the query param if it is configured. The header is checked first.
+// The query param is masked in the access log and the debug page.
+// The routes with metadata MetaAuthSkip are skipped.
+func APIKeyAuth(config APIKeyConfig) HandlerFunc {
+	if config.Header == "" {
+		config.Header = "X-API-Key"
+	}
+
+	return func(c *Context) {
+		c.hideHeader(config.Header)
+		if config.Query != "" {
+			c.hideQuery(config.Query)
+		}
+		if c.skipAuth() {
+			return
+		}
+		key := c.Header(config.Header)
+		if key == "" && config.Query != "" {
+			key = c.Query(config.Query)
+		}
+		var p *Principal
+		if key != "" {
+			p = config.Verify(key)
+		}
+		if p == nil {
+			c.unauthorized("", "invalid api key")
+			return
+		}
+		principal := *p
+		principal.Scheme = SchemeAPIKey
+		c.Set(principalKey, &principal)
+	}
+}
+
+// JWTConfig is the config for JWTAuth middleware.
+type JWTConfig struct {
+	HMACKey []byte         // The key for HS256 tokens, see jwt.MinHMACKeyLen
+	RSAKey  *rsa.PublicKey // The public key for RS256 tokens
+
+	Audience string        // The required audience, empty to skip the check
+	Issuer   string        // The required issuer, empty to skip the check
+	Leeway   time.Duration // The allowed clock skew for "exp" and "nbf"
+
+	// Create the principal by the claims. Default is the "sub" claim as ID,
+	// the "roles" claim as roles and the "scope" claim as permissions.
+	// Return nil to reject the token.
+	Principal func(claims jwt.Claims) *Principal
+}
+
+// JWTAuth authenticates the requests by the bearer token of JWT, the token
+// is signed by HS256 or RS256. The signature, the "exp", "nbf" claims, and
+// the audience and issuer if they are configured, are verified. The claims
+// are got by Context.Claims.
+// The routes with metadata MetaAuthSkip are skipped.
+//
+// It panics if no key is set, or the HMAC key is set but too short, such as
+// an unset environment variable, so the misconfigured server doesn't start.
+func JWTAuth(config JWTConfig) HandlerFunc {
+	parser, err := jwt.NewParser(config.HMACKey, config.RSAKey)
+	if err != nil {
+		panic(err)
+	}
+	parser.Audience = config.Audience
+	parser.Issuer = config.Issuer
+	parser.Leeway = config.Leeway
+	if config.Principal == nil {
+		config.Principal = claimsPrincipal
+	}
+
+	return func(c *Context) {
+		if c.skipAuth() {
+			return
+		}
+		auth := c.Header("Authorization")
+		if len(auth) < 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+			c.unauthorized("Bearer", "missing bearer token")
+			return
+		}
+		claims, err := parser.Parse(strings.TrimSpace(auth[7:]))
+		if err != nil {
+			c.unauthorized(`Bearer error="invalid_token"`, "invalid bearer token")
+			return
+		}
+		p := config.Principal(claims)
+		if p == nil {
+			c.unauthorized(`Bearer error="invalid_token"`, "invalid bearer token")
+			return
+		}
+		principal := *p
+		principal.Scheme = SchemeJWT
+		principal.Claims = claims
+		c.Set(principalKey, &principal)
+	}
+}
+
+// Create the principal by the standard claims.
+func claimsPrincipal(claims jwt.Claims) *Principal {
+	return &Principal{
+		ID:          claims.Subject(),
+		Roles:       claims.Strings("roles"),
+		Permissions: strings.Fields(claims.String("scope")),
+	}
+}
+
+// Is the matched route public.
+func (c *Context) skipAuth() bool {
+	skip, _ := c.Metadata(MetaAuthSkip)
+	return skip == true
+}
+
+// Reply 401 with the challenge.
+func (c *Context) unauthorized(challenge string, message string) {
+	if challenge != "" {
+		c.SetHeader("WWW-Authenticate", challenge)
+	}
+	c.Error(NewHTTPError(http.StatusUnauthorized, message))
+}
+
+// Get the principal authenticated by the auth middleware.
+// Return nil, when the request isn't authenticated.
+func (c *Context) Principal() *Principal {
+	if p, ok := c.Get(principalKey); ok {
+		return p.(*Principal)
+	}
+	return nil
+}
+
+// Get the claims of the JWT bearer token.
+// Return nil, when the request isn't authenticated by JWTAuth.
+func (c *Context) Claims() jwt.Claims {
+	if p := c.Principal(); p != nil {
+		return p.Claims
+	}
+	return nil
+}
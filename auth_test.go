@@ -0,0 +1,146 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"github.com/uestcer/light/jwt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func authMux(auth HandlerFunc) *Mux {
+	mux := NewMux("myMux")
+	mux.Use(auth)
+	mux.Add([]string{"GET"}, "/me", func(c *Context) {
+		p := c.Principal()
+		c.Text(http.StatusOK, p.Scheme+":"+p.ID+":"+strings.Join(p.Roles, ","))
+	})
+	mux.Add([]string{"GET"}, "/health", urlHandler).Meta(MetaAuthSkip, true)
+	mux.Group("/public").Meta(MetaAuthSkip, true).Add([]string{"GET"}, "/docs", urlHandler)
+	mux.Start()
+	return mux
+}
+
+func TestBasicAuth(t *testing.T) {
+	admin := &Principal{Roles: []string{"admin"}}
+	mux := authMux(BasicAuth(BasicAuthConfig{Realm: "light", Verify: func(user, password string) *Principal {
+		if user == "li" && password == "secret" {
+			return admin
+		}
+		return nil
+	}}))
+
+	r := httptest.NewRequest("GET", "/me", nil)
+	r.SetBasicAuth("li", "secret")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	assertTrue(w.Code == http.StatusOK && w.Body.String() == "basic:li:admin", "case1", t)
+	assertTrue(admin.ID == "" && admin.Scheme == "", "case1", t)
+
+	r = httptest.NewRequest("GET", "/me", nil)
+	r.SetBasicAuth("li", "wrong")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	assertTrue(w.Code == http.StatusUnauthorized, "case2", t)
+	assertTrue(w.Header().Get("WWW-Authenticate") == `Basic realm="light", charset="UTF-8"`, "case2", t)
+
+	w = serve(mux, "GET", "/me")
+	assertTrue(w.Code == http.StatusUnauthorized, "case3", t)
+
+	// the public routes
+	w = serve(mux, "GET", "/health")
+	assertTrue(w.Code == http.StatusOK, "case4", t)
+	w = serve(mux, "GET", "/public/docs")
+	assertTrue(w.Code == http.StatusOK, "case4", t)
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	mux := authMux(APIKeyAuth(APIKeyConfig{Query: "api_key", Verify: func(key string) *Principal {
+		if key == "k1" {
+			return &Principal{ID: "robot"}
+		}
+		return nil
+	}}))
+
+	w := serve(mux, "GET", "/me", "X-API-Key", "k1")
+	assertTrue(w.Code == http.StatusOK && w.Body.String() == "apikey:robot:", "case1", t)
+	w = serve(mux, "GET", "/me?api_key=k1")
+	assertTrue(w.Code == http.StatusOK, "case2", t)
+
+	// the header is checked first
+	w = serve(mux, "GET", "/me?api_key=k1", "X-API-Key", "k2")
+	assertTrue(w.Code == http.StatusUnauthorized, "case3", t)
+	w = serve(mux, "GET", "/me")
+	assertTrue(w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "", "case3", t)
+	w = serve(mux, "GET", "/health")
+	assertTrue(w.Code == http.StatusOK, "case4", t)
+
+	// the query is disabled by default
+	mux = authMux(APIKeyAuth(APIKeyConfig{Verify: func(key string) *Principal { return &Principal{} }}))
+	w = serve(mux, "GET", "/me?api_key=k1")
+	assertTrue(w.Code == http.StatusUnauthorized, "case5", t)
+}
+
+func TestJWTAuth(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	var claims jwt.Claims
+	shared := &Principal{ID: "shared"}
+	mux := NewMux("myMux")
+	mux.Use(JWTAuth(JWTConfig{HMACKey: key, Audience: "api", Principal: func(c jwt.Claims) *Principal {
+		if c.Subject() == "shared" {
+			return shared
+		}
+		return claimsPrincipal(c)
+	}}))
+	mux.Add([]string{"GET"}, "/me", func(c *Context) {
+		claims = c.Claims()
+		p := c.Principal()
+		c.Text(http.StatusOK, p.Scheme+":"+p.ID+":"+strings.Join(p.Roles, ",")+":"+strings.Join(p.Permissions, ","))
+	})
+	mux.Start()
+
+	token, _ := jwt.SignHS256(jwt.Claims{
+		"sub": "li", "aud": "api", "roles": []string{"admin", "dev"}, "scope": "users:read users:write",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}, key)
+	w := serve(mux, "GET", "/me", "Authorization", "Bearer "+token)
+	assertTrue(w.Code == http.StatusOK, "case1", t)
+	assertTrue(w.Body.String() == "jwt:li:admin,dev:users:read,users:write", "case1", t)
+	assertTrue(claims.Subject() == "li", "case1", t)
+
+	w = serve(mux, "GET", "/me", "Authorization", "Bearer "+token+"x")
+	assertTrue(w.Code == http.StatusUnauthorized, "case2", t)
+	assertTrue(w.Header().Get("WWW-Authenticate") == `Bearer error="invalid_token"`, "case2", t)
+	w = serve(mux, "GET", "/me")
+	assertTrue(w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "Bearer", "case3", t)
+
+	expired, _ := jwt.SignHS256(jwt.Claims{"sub": "li", "aud": "api", "exp": time.Now().Add(-time.Hour).Unix()}, key)
+	w = serve(mux, "GET", "/me", "Authorization", "Bearer "+expired)
+	assertTrue(w.Code == http.StatusUnauthorized, "case4", t)
+	other, _ := jwt.SignHS256(jwt.Claims{"sub": "li", "aud": "web"}, key)
+	w = serve(mux, "GET", "/me", "Authorization", "Bearer "+other)
+	assertTrue(w.Code == http.StatusUnauthorized, "case4", t)
+
+	// the shared principal is copied
+	token, _ = jwt.SignHS256(jwt.Claims{"sub": "shared", "aud": "api"}, key)
+	w = serve(mux, "GET", "/me", "Authorization", "Bearer "+token)
+	assertTrue(w.Code == http.StatusOK && w.Body.String() == "jwt:shared::", "case5", t)
+	assertTrue(shared.Scheme == "" && shared.Claims == nil, "case5", t)
+}
+
+func TestJWTAuthKey(t *testing.T) {
+	panics := func(config JWTConfig) (p bool) {
+		defer func() { p = recover() != nil }()
+		JWTAuth(config)
+		return
+	}
+	assertTrue(panics(JWTConfig{}), "case1", t)
+	assertTrue(panics(JWTConfig{HMACKey: []byte("")}), "case1", t)
+	assertTrue(panics(JWTConfig{HMACKey: []byte("secret")}), "case1", t)
+	assertTrue(!panics(JWTConfig{HMACKey: make([]byte, jwt.MinHMACKeyLen)}), "case2", t)
+}
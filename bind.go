@@ -0,0 +1,306 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/arging/utils/errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Struct tags for binding the request values into struct fields.
+const (
+	paramTag  = "param"  // Path param of the routing result
+	queryTag  = "query"  // Query param of the request url
+	formTag   = "form"   // Form field, including url query and body form
+	headerTag = "header" // Request header
+)
+
+// The max memory for parsing multipart form.
+const maxFormMemory = 32 << 20
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+)
+
+// FieldError is the error for binding a struct field.
+type FieldError struct {
+	Field string // The struct field name, nested field is joined by "."
+	Tag   string // The source tag, such as "param" and "query"
+	Key   string // The key in the source
+	Value string // The value failed to convert
+	Err   error  // The convert error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("bind field %s from %s %q error: %v", e.Field, e.Tag, e.Key, e.Err)
+}
+
+// FieldErrors is the errors of all failed fields.
+type FieldErrors []*FieldError
+
+func (errs FieldErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Bind the request into the struct pointed by v.
+//
+// The JSON or XML body is decoded by the request content type first, then
+// the fields are filled by the struct tags:
+//
+//	type Query struct {
+//		Id    int      `param:"id"`         // path param
+//		Tags  []string `query:"tag"`        // query param
+//		Name  string   `form:"name"`        // form field
+//		Token string   `header:"X-Token"`   // request header
+//		Page  *Page                          // nested struct without tag
+//	}
+//
+// Slice field gets all the values, other field gets the first value.
+// Missing value leaves the field unchanged. If some fields failed to convert,
+// the others are still filled and the FieldErrors is returned.
+func (c *Context) Bind(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.Newf("bind target must be a struct pointer, got %T.", v)
+	}
+
+	if err := c.bindBody(v); err != nil {
+		return err
+	}
+
+	var errs FieldErrors
+	seen := map[reflect.Type]bool{rv.Elem().Type(): true}
+	if _, err := c.bindStruct(rv.Elem(), "", seen, &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Decode the JSON or XML body into v.
+func (c *Context) bindBody(v interface{}) error {
+	r := c.Request
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+
+	ctype, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var err error
+	switch {
+	case ctype == "application/json" || strings.HasSuffix(ctype, "+json"):
+		err = json.NewDecoder(r.Body).Decode(v)
+	case ctype == "application/xml" || ctype == "text/xml" || strings.HasSuffix(ctype, "+xml"):
+		err = xml.NewDecoder(r.Body).Decode(v)
+	default:
+		return nil
+	}
+
+	if err != nil && err != io.EOF {
+		return errors.Wrapf(err, "bind %s body error.", ctype)
+	}
+	return nil
+}
+
+// Fill the tagged fields of the struct, the seen types are the struct pointers
+// being filled. Return true, when any field has values.
+func (c *Context) bindStruct(rv reflect.Value, prefix string, seen map[reflect.Type]bool, errs *FieldErrors) (bool, error) {
+	bound := false
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous { // unexported
+			continue
+		}
+		field := rv.Field(i)
+		name := prefix + sf.Name
+
+		tag, key := bindTag(sf)
+		if tag == "" {
+			ok, err := c.bindNested(field, name, seen, errs)
+			if err != nil {
+				return bound, err
+			}
+			bound = bound || ok
+			continue
+		}
+
+		values, err := c.bindValues(tag, key)
+		if err != nil {
+			return bound, err
+		}
+		if len(values) == 0 {
+			continue
+		}
+
+		bound = true
+		if err := setField(field, values); err != nil {
+			*errs = append(*errs, &FieldError{name, tag, key, strings.Join(values, ","), err})
+		}
+	}
+	return bound, nil
+}
+
+// Fill the nested struct field, which has no binding tag.
+// The nil pointer is set only when any field has values, and the struct
+// pointing to its own type, such as a linked list node, isn't filled again.
+func (c *Context) bindNested(field reflect.Value, name string, seen map[reflect.Type]bool, errs *FieldErrors) (bool, error) {
+	ft := field.Type()
+	if ft.Kind() == reflect.Ptr {
+		ft = ft.Elem()
+	}
+	if ft.Kind() != reflect.Struct || ft == timeType || !field.CanSet() {
+		return false, nil
+	}
+	if field.Kind() != reflect.Ptr {
+		return c.bindStruct(field, name+".", seen, errs)
+	}
+	if seen[ft] {
+		return false, nil
+	}
+
+	seen[ft] = true
+	defer delete(seen, ft)
+	elem := field
+	if field.IsNil() {
+		elem = reflect.New(ft)
+	}
+	bound, err := c.bindStruct(elem.Elem(), name+".", seen, errs)
+	if bound && field.IsNil() {
+		field.Set(elem)
+	}
+	return bound, err
+}
+
+// Get the source tag and key of the field.
+func bindTag(sf reflect.StructField) (string, string) {
+	for _, tag := range []string{paramTag, queryTag, formTag, headerTag} {
+		if key, ok := sf.Tag.Lookup(tag); ok && key != "-" {
+			if key == "" {
+				key = sf.Name
+			}
+			return tag, key
+		}
+	}
+	return "", ""
+}
+
+// Get the values of the key from the source.
+func (c *Context) bindValues(tag string, key string) ([]string, error) {
+	switch tag {
+	case paramTag:
+		return c.ParamValues(key), nil
+	case queryTag:
+		return c.QueryValues()[key], nil
+	case headerTag:
+		return c.Request.Header.Values(key), nil
+	}
+
+	r := c.Request
+	if r.Form == nil {
+		var err error
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			err = r.ParseMultipartForm(maxFormMemory)
+		} else {
+			err = r.ParseForm()
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "bind form error.")
+		}
+	}
+	return r.Form[key], nil
+}
+
+// Convert the values into the field.
+func setField(field reflect.Value, values []string) error {
+	if field.Kind() == reflect.Slice && !field.Type().Implements(textUnmarshalerType) &&
+		field.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setValue(slice.Index(i), value); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	return setValue(field, values[0])
+}
+
+// Convert the string value into the field.
+func setValue(field reflect.Value, value string) error {
+	if field.Kind() == reflect.Ptr {
+		v := reflect.New(field.Type().Elem())
+		if err := setValue(v.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(v)
+		return nil
+	}
+
+	if field.CanAddr() && field.Addr().Type().Implements(textUnmarshalerType) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice: // []byte
+		field.SetBytes([]byte(value))
+	default:
+		return errors.Newf("unsupported field type: %s.", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type bindPage struct {
+	Num  int `query:"page"`
+	Size int `query:"size"`
+}
+
+type bindUser struct {
+	Id      int64         `param:"id"`
+	Names   []string      `param:"name"`
+	Tags    []string      `query:"tag"`
+	Debug   bool          `query:"debug"`
+	Timeout time.Duration `query:"timeout"`
+	Score   *float64      `form:"score"`
+	Token   string        `header:"X-Token"`
+	Email   string        `json:"email" xml:"email"`
+	Age     uint8         `json:"age" xml:"age"`
+	Page    *bindPage
+	Skip    string `query:"-"`
+}
+
+// Serve a request to the bind handler and return the bind error.
+func bindRequest(r *http.Request, v interface{}) error {
+	var err error
+	mux := NewMux("myMux")
+	mux.Add([]string{"GET", "POST"}, "/users/(id)/(name)/(name)", func(c *Context) {
+		err = c.Bind(v)
+	})
+	mux.Start()
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+	return err
+}
+
+func TestBind(t *testing.T) {
+	r := httptest.NewRequest("POST", "/users/12/li/lee?tag=a&tag=b&debug=true&timeout=2s&page=3&Skip=x",
+		strings.NewReader(`{"email":"li@example.com","age":20}`))
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	r.Header.Set("X-Token", "secret")
+
+	u := &bindUser{}
+	err := bindRequest(r, u)
+	assertTrue(err == nil, "case1", t)
+	assertTrue(u.Id == 12, "case1", t)
+	assertTrue(len(u.Names) == 2 && u.Names[1] == "lee", "case2", t)
+	assertTrue(len(u.Tags) == 2 && u.Tags[0] == "a", "case3", t)
+	assertTrue(u.Debug && u.Timeout == 2*time.Second, "case4", t)
+	assertTrue(u.Score == nil, "case5", t)
+	assertTrue(u.Token == "secret", "case6", t)
+	assertTrue(u.Email == "li@example.com" && u.Age == 20, "case7", t)
+	assertTrue(u.Page != nil && u.Page.Num == 3 && u.Page.Size == 0, "case8", t)
+	assertTrue(u.Skip == "", "case9", t)
+}
+
+func TestBindForm(t *testing.T) {
+	r := httptest.NewRequest("POST", "/users/1/a/b", strings.NewReader("score=9.5"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	u := &bindUser{}
+	err := bindRequest(r, u)
+	assertTrue(err == nil, "case1", t)
+	assertTrue(u.Score != nil && *u.Score == 9.5, "case1", t)
+	assertTrue(u.Page == nil, "case2", t)
+}
+
+type bindNode struct {
+	Name  string `query:"name"`
+	Next  *bindNode
+	Child struct {
+		Parent *bindNode
+	}
+}
+
+func TestBindRecursive(t *testing.T) {
+	n := &bindNode{}
+	err := bindRequest(httptest.NewRequest("GET", "/users/1/a/b?name=li", nil), n)
+	assertTrue(err == nil && n.Name == "li", "case1", t)
+	assertTrue(n.Next == nil && n.Child.Parent == nil, "case2", t)
+}
+
+func TestBindXML(t *testing.T) {
+	r := httptest.NewRequest("POST", "/users/1/a/b",
+		strings.NewReader(`<user><email>li@example.com</email><age>30</age></user>`))
+	r.Header.Set("Content-Type", "application/xml")
+
+	u := &bindUser{}
+	err := bindRequest(r, u)
+	assertTrue(err == nil, "case1", t)
+	assertTrue(u.Email == "li@example.com" && u.Age == 30, "case1", t)
+}
+
+func TestBindErrors(t *testing.T) {
+	r1 := httptest.NewRequest("GET", "/users/abc/a/b?debug=yes&page=2", nil)
+	u1 := &bindUser{}
+	err1 := bindRequest(r1, u1)
+	errs, ok := err1.(FieldErrors)
+	assertTrue(ok && len(errs) == 2, "case1", t)
+	assertTrue(errs[0].Field == "Id" && errs[0].Tag == "param" && errs[0].Value == "abc", "case1", t)
+	assertTrue(errs[1].Field == "Debug" && errs[1].Key == "debug", "case1", t)
+	// other fields are still filled
+	assertTrue(u1.Page.Num == 2, "case1", t)
+
+	r2 := httptest.NewRequest("POST", "/users/1/a/b", strings.NewReader(`{"email":`))
+	r2.Header.Set("Content-Type", "application/json")
+	err2 := bindRequest(r2, &bindUser{})
+	_, ok = err2.(FieldErrors)
+	assertTrue(err2 != nil && !ok, "case2", t)
+
+	r3 := httptest.NewRequest("GET", "/users/1/a/b", nil)
+	var u3 bindUser
+	assertTrue(bindRequest(r3, u3) != nil, "case3", t)
+}
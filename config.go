@@ -0,0 +1,493 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/arging/utils/errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Profiles of the config.
+const (
+	ProfileDev  = "dev"
+	ProfileTest = "test"
+	ProfileProd = "prod"
+)
+
+// The environment variable of the config profile.
+const EnvProfile = "LIGHT_PROFILE"
+
+// Struct tags for binding the config into struct fields.
+const (
+	configTag  = "config"  // The config key, such as `config:"port,required"`
+	defaultTag = "default" // The default value, such as `default:"8080"`
+)
+
+// The key separator of the config.
+const keySep = "."
+
+// Config is the app configuration, such as the port, the timeouts and the
+// template paths. The values are loaded from JSON or INI files, then they are
+// overridden by the environment variables and the command-line flags:
+//
+//	config := light.NewConfig("")
+//	if err := config.LoadFiles("conf", "app"); err != nil {
+//		log.Fatal(err)
+//	}
+//	config.LoadEnv("APP")
+//	config.LoadFlags(flag.CommandLine)
+//
+//	var server struct {
+//		Port    int           `config:"port,required"`
+//		Timeout time.Duration `default:"30s"`
+//	}
+//	err := config.Bind("server", &server)
+//
+// The keys are case-insensitive, and the nested keys are joined by ".",
+// such as "server.port".
+type Config struct {
+	profile string
+	values  map[string][]string // key -> values
+	sources map[string]string   // key -> source of the value
+	guessed map[string]bool     // the keys guessed from the environment variables
+}
+
+// Create an empty config of the profile, such as "dev" and "prod".
+// If the profile is empty, it is the environment variable LIGHT_PROFILE,
+// or "dev" if the variable is not set.
+func NewConfig(profile string) *Config {
+	if profile == "" {
+		profile = os.Getenv(EnvProfile)
+	}
+	if profile == "" {
+		profile = ProfileDev
+	}
+	return &Config{
+		profile: strings.ToLower(profile),
+		values:  make(map[string][]string),
+		sources: make(map[string]string),
+		guessed: make(map[string]bool),
+	}
+}
+
+// Get the profile of the config.
+func (c *Config) Profile() string {
+	return c.profile
+}
+
+// Set the value of the key.
+func (c *Config) Set(key string, value string) {
+	c.set(key, []string{value}, "set")
+}
+
+func (c *Config) set(key string, values []string, source string) {
+	key = strings.ToLower(key)
+	c.values[key] = values
+	c.sources[key] = source
+	delete(c.guessed, key)
+}
+
+// Get the value of the key, multiple values are joined by ",".
+// Return false, when the key is not set.
+func (c *Config) Get(key string) (string, bool) {
+	values, ok := c.values[strings.ToLower(key)]
+	return strings.Join(values, ","), ok
+}
+
+// Get the sorted keys of the config.
+func (c *Config) Keys() []string {
+	keys := make([]string, 0, len(c.values))
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Load the config files in the dir by the base name and the profile.
+// The base file, such as "app.json" or "app.ini", is loaded first, then the
+// profile file, such as "app.prod.json", overrides it if it exists.
+func (c *Config) LoadFiles(dir string, name string) errors.Error {
+	base, err := c.findFile(dir, name)
+	if err != nil {
+		return err
+	}
+	if base == "" {
+		return errors.Newf("config file not found: %s.", filepath.Join(dir, name))
+	}
+	if err := c.LoadFile(base); err != nil {
+		return err
+	}
+
+	profile, err := c.findFile(dir, name+keySep+c.profile)
+	if err != nil || profile == "" {
+		return err
+	}
+	return c.LoadFile(profile)
+}
+
+// Find the config file by the name without extension.
+// Return empty string, when no file is found.
+func (c *Config) findFile(dir string, name string) (string, errors.Error) {
+	var found string
+	for _, ext := range []string{".json", ".ini"} {
+		filename := filepath.Join(dir, name+ext)
+		if _, err := os.Stat(filename); err != nil {
+			continue
+		}
+		if found != "" {
+			return "", errors.Newf("config file conflict: %s, %s.", found, filename)
+		}
+		found = filename
+	}
+	return found, nil
+}
+
+// Load the config file, the format is JSON for ".json" file, and INI for
+// ".ini" or ".conf" file.
+func (c *Config) LoadFile(filename string) errors.Error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return errors.Wrapf(err, "config read error: %s.", filename)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
+	case ".json":
+		return c.LoadJSON(data, filename)
+	case ".ini", ".conf":
+		return c.LoadINI(data, filename)
+	default:
+		return errors.Newf("config format error, unknown extension: %s.", filename)
+	}
+}
+
+// Load the JSON config, the nested objects are flattened into the keys
+// joined by ".", and the arrays are the multiple values.
+// The source is used in the error messages, such as the file name.
+func (c *Config) LoadJSON(data []byte, source string) errors.Error {
+	var obj map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&obj); err != nil {
+		return errors.Wrapf(err, "config json error: %s.", source)
+	}
+	return c.loadObject("", obj, source)
+}
+
+// Flatten the JSON object into the config.
+func (c *Config) loadObject(prefix string, obj map[string]interface{}, source string) errors.Error {
+	for k, v := range obj {
+		key := prefix + k
+		switch v := v.(type) {
+		case nil:
+		case map[string]interface{}:
+			if err := c.loadObject(key+keySep, v, source); err != nil {
+				return err
+			}
+		case []interface{}:
+			values := make([]string, 0, len(v))
+			for _, elem := range v {
+				switch elem.(type) {
+				case map[string]interface{}, []interface{}:
+					return errors.Newf("config json error, nested array of %s: %s.", key, source)
+				}
+				values = append(values, fmt.Sprint(elem))
+			}
+			c.set(key, values, source)
+		default:
+			c.set(key, []string{fmt.Sprint(v)}, source)
+		}
+	}
+	return nil
+}
+
+// Load the INI config, the section is the key prefix:
+//
+//	; comment
+//	name = myApp
+//	[server]
+//	port = 8080       ; the key is "server.port"
+//	host = "0.0.0.0"  ; the quotes are trimmed
+//
+// The source is used in the error messages, such as the file name.
+func (c *Config) LoadINI(data []byte, source string) errors.Error {
+	prefix := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			if line[len(line)-1] != ']' {
+				return errors.Newf("config ini error, invalid section at line %d: %s.", n, source)
+			}
+			prefix = strings.TrimSpace(line[1:len(line)-1]) + keySep
+			if prefix == keySep {
+				prefix = ""
+			}
+			continue
+		}
+
+		i := strings.IndexByte(line, '=')
+		if i <= 0 {
+			return errors.Newf("config ini error, invalid line %d: %s.", n, source)
+		}
+		key := strings.TrimSpace(line[:i])
+		c.set(prefix+key, []string{iniValue(line[i+1:])}, source)
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Wrapf(err, "config ini error: %s.", source)
+	}
+	return nil
+}
+
+// Get the INI value, the inline comment and the quotes are trimmed.
+func iniValue(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') {
+		if i := strings.IndexByte(s[1:], s[0]); i >= 0 {
+			return s[1 : i+1]
+		}
+	}
+	if i := strings.Index(s, " ;"); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+// Load the environment variables with the prefix, such as "APP".
+// The variable APP_SERVER_PORT overrides the key "server.port". If the key
+// isn't loaded, the "_" is guessed as ".", such as APP_SERVER_READ_TIMEOUT to
+// "server.read.timeout", and Bind matches it with the field key, such as
+// "server.read_timeout".
+func (c *Config) LoadEnv(prefix string) {
+	prefix = strings.ToUpper(prefix) + "_"
+	known := make(map[string]string, len(c.values))
+	for key := range c.values {
+		known[envName(key)] = key
+	}
+
+	for _, env := range os.Environ() {
+		i := strings.IndexByte(env, '=')
+		name := strings.ToUpper(env[:i])
+		if i <= len(prefix) || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		name = name[len(prefix):]
+		key, ok := known[name]
+		if !ok {
+			key = guessKey(name)
+		}
+		c.set(key, []string{env[i+1:]}, "env "+env[:i])
+		if !ok {
+			c.guessed[key] = true
+		}
+	}
+}
+
+// Get the environment variable name of the key, without prefix.
+func envName(key string) string {
+	return strings.ToUpper(strings.Replace(key, keySep, "_", -1))
+}
+
+// Guess the key of the environment variable name, without prefix.
+func guessKey(name string) string {
+	return strings.Replace(strings.ToLower(name), "_", keySep, -1)
+}
+
+// Load the flags which are set on the command line, the flag name is the key,
+// such as "-server.port=8080". The flags should be parsed before.
+func (c *Config) LoadFlags(fs *flag.FlagSet) {
+	fs.Visit(func(f *flag.Flag) {
+		c.set(f.Name, []string{f.Value.String()}, "flag -"+f.Name)
+	})
+}
+
+// Bind the config into the struct pointed by v, the keys are under the prefix.
+// If the prefix is empty, all keys are bound.
+//
+// The key of field is the "config" tag, or the snake case of the field name.
+// The nested struct field is bound by the key prefix:
+//
+//	type ServerConfig struct {
+//		Port        int           `config:"port,required"`
+//		ReadTimeout time.Duration `default:"30s"`  // key "read_timeout"
+//		Hosts       []string      // values of JSON array, or "a,b" string
+//		TLS         TLSConfig     `config:"tls"`    // keys "tls.*"
+//		Ignored     string        `config:"-"`
+//	}
+//
+// The nested struct pointer is set only when a key is under its prefix.
+//
+// It fails, if a required key is missing, a value failed to convert, or a key
+// under the prefix has no field. Then all the problems are reported.
+func (c *Config) Bind(prefix string, v interface{}) errors.Error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.Newf("config bind error, not a struct pointer: %T.", v)
+	}
+
+	prefix = strings.ToLower(prefix)
+	if prefix != "" {
+		prefix += keySep
+	}
+	used := make(map[string]bool)
+	var errs FieldErrors
+	c.bindStruct(rv.Elem(), prefix, "", used, &errs)
+
+	var unknown []string
+	for key := range c.values {
+		if strings.HasPrefix(key, prefix) && !used[key] {
+			unknown = append(unknown, key+" ("+c.sources[key]+")")
+		}
+	}
+	sort.Strings(unknown)
+
+	switch {
+	case len(errs) > 0 && len(unknown) > 0:
+		return errors.Wrapf(errs, "config bind error, unknown keys: %s.", strings.Join(unknown, ", "))
+	case len(errs) > 0:
+		return errors.Wrapf(errs, "config bind error: %s.", strings.TrimSuffix(prefix, keySep))
+	case len(unknown) > 0:
+		return errors.Newf("config bind error, unknown keys: %s.", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// Fill the fields of the struct by the keys under the prefix.
+func (c *Config) bindStruct(rv reflect.Value, prefix string, path string, used map[string]bool, errs *FieldErrors) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		name, options := splitTag(sf.Tag.Get(configTag))
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = snakeCase(sf.Name)
+		}
+		key := prefix + strings.ToLower(name)
+		field := rv.Field(i)
+
+		if isNestedConfig(sf.Type) {
+			if field.Kind() == reflect.Ptr {
+				if !c.hasPrefix(key + keySep) {
+					// Not configured, and the struct pointing to its own type
+					// stops here.
+					continue
+				}
+				if field.IsNil() {
+					field.Set(reflect.New(sf.Type.Elem()))
+				}
+				field = field.Elem()
+			}
+			c.bindStruct(field, key+keySep, path+sf.Name+keySep, used, errs)
+			continue
+		}
+
+		values, ok := c.values[key]
+		source := c.sources[key]
+		if guess := guessKey(envName(key)); c.guessed[guess] {
+			// Set by the environment variable before the key is known,
+			// the key set later wins.
+			if !ok {
+				values, source, ok = c.values[guess], c.sources[guess], true
+			}
+			used[guess] = true
+		}
+		if ok && len(values) == 0 && field.Kind() != reflect.Slice {
+			// The empty array is unset for the single value, such as "addr": [].
+			used[key] = true
+			ok = false
+		}
+		if !ok {
+			def, hasDefault := sf.Tag.Lookup(defaultTag)
+			switch {
+			case hasDefault:
+				values, source = []string{def}, "default"
+			case options == "required":
+				*errs = append(*errs, &FieldError{path + sf.Name, configTag, key, "",
+					errors.New("missing required key")})
+				continue
+			default:
+				continue
+			}
+		}
+		used[key] = true
+
+		if field.Kind() == reflect.Slice && len(values) == 1 && field.Type().Elem().Kind() != reflect.Uint8 {
+			values = strings.Split(values[0], ",")
+			for j := range values {
+				values[j] = strings.TrimSpace(values[j])
+			}
+		}
+		if err := setField(field, values); err != nil {
+			*errs = append(*errs, &FieldError{path + sf.Name, configTag, key + " (" + source + ")",
+				strings.Join(values, ","), err})
+		}
+	}
+}
+
+// Is any key set under the prefix, including the guessed keys of the
+// environment variables.
+func (c *Config) hasPrefix(prefix string) bool {
+	guess := guessKey(envName(prefix))
+	for key := range c.values {
+		if strings.HasPrefix(key, prefix) || c.guessed[key] && strings.HasPrefix(key, guess) {
+			return true
+		}
+	}
+	return false
+}
+
+// Is the field type a nested config struct.
+func isNestedConfig(ft reflect.Type) bool {
+	if ft.Kind() == reflect.Ptr {
+		ft = ft.Elem()
+	}
+	return ft.Kind() == reflect.Struct && ft != timeType &&
+		!reflect.PtrTo(ft).Implements(textUnmarshalerType)
+}
+
+// Split the tag into the name and the options.
+func splitTag(tag string) (string, string) {
+	if i := strings.IndexByte(tag, ','); i >= 0 {
+		return tag[:i], tag[i+1:]
+	}
+	return tag, ""
+}
+
+// Convert the field name into snake case, such as "ReadTimeout" to
+// "read_timeout" and "TLSCert" to "tls_cert".
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var buf bytes.Buffer
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 &&
+			(unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			buf.WriteByte('_')
+		}
+		buf.WriteRune(unicode.ToLower(r))
+	}
+	return buf.String()
+}
@@ -0,0 +1,186 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testServerConfig struct {
+	Port        int           `config:"port,required"`
+	ReadTimeout time.Duration `default:"30s"`
+	Hosts       []string
+	Debug       bool
+	TLS         struct {
+		CertFile string
+	} `config:"tls"`
+	Ignored string `config:"-"`
+}
+
+func writeConfig(t *testing.T, dir string, name string, content string) {
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "app.json", `{
+		"name": "myApp",
+		"server": {"port": 8080, "hosts": ["a", "b"], "tls": {"cert_file": "dev.pem"}}
+	}`)
+	writeConfig(t, dir, "app.prod.ini", `
+; production
+[server]
+port = 80   ; http
+debug = "false"
+[server.tls]
+cert_file = prod.pem
+`)
+
+	config := NewConfig("dev")
+	assertTrue(config.LoadFiles(dir, "app") == nil, "case1", t)
+	var sc testServerConfig
+	assertTrue(config.Bind("server", &sc) == nil, "case1", t)
+	assertTrue(sc.Port == 8080 && sc.ReadTimeout == 30*time.Second, "case1", t)
+	assertTrue(len(sc.Hosts) == 2 && sc.Hosts[1] == "b" && sc.TLS.CertFile == "dev.pem", "case1", t)
+	name, _ := config.Get("NAME")
+	assertTrue(name == "myApp", "case1", t)
+
+	// the profile file overrides the base file
+	config = NewConfig("PROD")
+	assertTrue(config.LoadFiles(dir, "app") == nil && config.Profile() == "prod", "case2", t)
+	sc = testServerConfig{}
+	assertTrue(config.Bind("server", &sc) == nil, "case2", t)
+	assertTrue(sc.Port == 80 && !sc.Debug && sc.TLS.CertFile == "prod.pem", "case2", t)
+
+	os.Setenv(EnvProfile, "test")
+	defer os.Unsetenv(EnvProfile)
+	assertTrue(NewConfig("").Profile() == "test", "case3", t)
+	os.Unsetenv(EnvProfile)
+	assertTrue(NewConfig("").Profile() == ProfileDev, "case3", t)
+
+	assertTrue(NewConfig("").LoadFiles(dir, "none") != nil, "case4", t)
+	writeConfig(t, dir, "app.ini", "a = 1")
+	assertTrue(NewConfig("").LoadFiles(dir, "app") != nil, "case4", t)
+}
+
+func TestConfigOverride(t *testing.T) {
+	config := NewConfig("")
+	config.LoadINI([]byte("[server]\nport = 8080\nread_timeout = 1s"), "test.ini")
+
+	os.Setenv("MYAPP_SERVER_PORT", "9090")
+	os.Setenv("MYAPP_SERVER_READ_TIMEOUT", "2s")
+	os.Setenv("MYAPP_SERVER_HOSTS", "a, b,c")
+	defer os.Unsetenv("MYAPP_SERVER_PORT")
+	defer os.Unsetenv("MYAPP_SERVER_READ_TIMEOUT")
+	defer os.Unsetenv("MYAPP_SERVER_HOSTS")
+	config.LoadEnv("myapp")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("server.port", 0, "")
+	fs.Bool("server.debug", false, "")
+	fs.Parse([]string{"-server.port=7070"})
+	config.LoadFlags(fs)
+
+	var sc testServerConfig
+	assertTrue(config.Bind("server", &sc) == nil, "case1", t)
+	assertTrue(sc.Port == 7070 && sc.ReadTimeout == 2*time.Second && !sc.Debug, "case1", t)
+	assertTrue(len(sc.Hosts) == 3 && sc.Hosts[1] == "b", "case1", t)
+	assertTrue(strings.Join(config.Keys(), " ") == "server.hosts server.port server.read_timeout", "case1", t)
+
+	// the key isn't loaded before
+	os.Setenv("MYAPP_SERVER_TLS_CERT_FILE", "env.pem")
+	defer os.Unsetenv("MYAPP_SERVER_TLS_CERT_FILE")
+	config = NewConfig("")
+	config.Set("server.port", "80")
+	config.LoadEnv("myapp")
+	sc = testServerConfig{}
+	assertTrue(config.Bind("server", &sc) == nil, "case2", t)
+	assertTrue(sc.Port == 9090 && sc.ReadTimeout == 2*time.Second && sc.TLS.CertFile == "env.pem", "case2", t)
+
+	// the key loaded after the environment variables wins
+	config.Set("server.read_timeout", "3s")
+	assertTrue(config.Bind("server", &sc) == nil && sc.ReadTimeout == 3*time.Second, "case3", t)
+}
+
+func TestConfigBindError(t *testing.T) {
+	config := NewConfig("")
+	var sc testServerConfig
+	err := config.Bind("server", &sc)
+	assertTrue(err != nil && strings.Contains(err.Error(), "missing required key"), "case1", t)
+
+	config.LoadJSON([]byte(`{"server": {"port": "abc", "prot": 1, "read_timeout": "1x"}}`), "app.json")
+	err = config.Bind("server", &sc)
+	msg := err.Error()
+	assertTrue(strings.Contains(msg, "server.port (app.json)"), "case2", t)
+	assertTrue(strings.Contains(msg, "server.read_timeout (app.json)"), "case2", t)
+	assertTrue(strings.Contains(msg, "unknown keys: server.prot (app.json)"), "case2", t)
+
+	// the empty array is unset for the single value
+	config = NewConfig("")
+	config.LoadJSON([]byte(`{"server": {"port": [], "read_timeout": [], "hosts": []}}`), "app.json")
+	err = config.Bind("server", &sc)
+	assertTrue(err != nil && strings.Contains(err.Error(), "missing required key"), "case3", t)
+	config.Set("server.port", "80")
+	sc = testServerConfig{Hosts: []string{"a"}}
+	assertTrue(config.Bind("server", &sc) == nil, "case3", t)
+	assertTrue(sc.ReadTimeout == 30*time.Second && len(sc.Hosts) == 0, "case3", t)
+
+	assertTrue(config.Bind("", sc) != nil, "case4", t)
+	assertTrue(config.LoadJSON([]byte(`{"a": [[1]]}`), "a.json") != nil, "case4", t)
+	assertTrue(config.LoadJSON([]byte(`[1]`), "a.json") != nil, "case4", t)
+	assertTrue(config.LoadINI([]byte("[a\nb=1"), "a.ini") != nil, "case4", t)
+	assertTrue(config.LoadINI([]byte("=1"), "a.ini") != nil, "case4", t)
+	assertTrue(config.LoadFile("a.yaml") != nil, "case4", t)
+}
+
+type testTreeConfig struct {
+	Name  string
+	Child *testTreeConfig
+}
+
+func TestConfigBindRecursive(t *testing.T) {
+	config := NewConfig("")
+	config.LoadJSON([]byte(`{"tree": {"name": "a", "child": {"name": "b"}}}`), "app.json")
+	var tc testTreeConfig
+	assertTrue(config.Bind("tree", &tc) == nil, "case1", t)
+	assertTrue(tc.Name == "a" && tc.Child != nil && tc.Child.Name == "b", "case1", t)
+	assertTrue(tc.Child.Child == nil, "case2", t)
+}
+
+func TestAppConfig(t *testing.T) {
+	app := NewApp("myApp")
+	app.Config.LoadINI([]byte("[server]\naddr = :9999\nread_timeout = 5s\nshutdown_timeout = 1s"), "app.ini")
+	assertTrue(app.Start() == nil, "case1", t)
+	assertTrue(app.Server.Addr == ":9999" && app.Server.ReadTimeout == 5*time.Second, "case1", t)
+	assertTrue(app.ShutdownTimeout == time.Second && app.Server.WriteTimeout == 0, "case1", t)
+
+	app = NewApp("myApp")
+	app.Config.Set("server.port", "80")
+	assertTrue(app.Start() != nil, "case2", t)
+
+	app = NewApp("myApp")
+	app.Config.LoadJSON([]byte(`{"server": {"addr": []}}`), "app.json")
+	assertTrue(app.Start() == nil && app.Server.Addr == "", "case3", t)
+
+	os.Setenv("MYAPP_SERVER_READ_TIMEOUT", "5s")
+	defer os.Unsetenv("MYAPP_SERVER_READ_TIMEOUT")
+	app = NewApp("myApp")
+	app.Config.LoadEnv("myapp")
+	assertTrue(app.Start() == nil && app.Server.ReadTimeout == 5*time.Second, "case4", t)
+}
+
+func TestSnakeCase(t *testing.T) {
+	assertTrue(snakeCase("ReadTimeout") == "read_timeout", "case1", t)
+	assertTrue(snakeCase("TLSCert") == "tls_cert", "case2", t)
+	assertTrue(snakeCase("ID") == "id" && snakeCase("UserID") == "user_id", "case3", t)
+	assertTrue(iniValue(`"a ; b" ; c`) == "a ; b" && iniValue("a # b") == "a", "case4", t)
+}
@@ -0,0 +1,9 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+const (
+	pathSep = "/" // Url path separator
+)
@@ -0,0 +1,220 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"github.com/uestcer/light/router"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// The index for aborted context, it is large enough to stop the chain.
+const abortIndex = math.MaxInt32 / 2
+
+// Context is the request object for handlers.
+// It wraps the response, the request and the routing result.
+// Context is pooled by the Mux, so it must not be used after the handler
+// returns, such as in a goroutine started by the handler.
+type Context struct {
+	Response ResponseWriter
+	Request  *http.Request
+	Result   *router.Result
+
+	mux      *Mux
+	writer   responseWriter
+	handlers []HandlerFunc // the middleware chain and handler
+	index    int           // the running handler index
+	query    url.Values    // the parsed query cache
+	format   string        // the format suffix stripped from url
+	keys     map[string]interface{}
+	route    *Route          // the matched route
+	group    *RouteGroup     // the group whose middleware is running
+	fellBack bool            // is the fallback handling the request
+	meta     router.Metadata // the metadata of the route or the fallback
+	start    time.Time       // the time when the request is received
+	routed   time.Time       // the time when the routing is done
+}
+
+// Reset the context for a new request.
+func (c *Context) reset(w http.ResponseWriter, r *http.Request, result *router.Result) {
+	c.writer.reset(w)
+	c.Response = &c.writer
+	c.Request = r
+	c.Result = result
+	c.handlers = nil
+	c.index = -1
+	c.query = nil
+	c.format = ""
+	c.keys = nil
+	c.route = nil
+	c.group = nil
+	c.fellBack = false
+	c.meta = result.Meta
+}
+
+// Run the handlers in the chain.
+func (c *Context) run(handlers []HandlerFunc) {
+	c.handlers = handlers
+	c.index = -1
+	c.Next()
+}
+
+// Run the next handlers in the chain. It should only be called in middleware,
+// the logic after Next runs after the next handlers return.
+func (c *Context) Next() {
+	c.index++
+	for c.index < len(c.handlers) {
+		c.handlers[c.index](c)
+		c.index++
+	}
+}
+
+// Abort the chain, the next handlers will not be called.
+// Abort doesn't stop current handler.
+func (c *Context) Abort() {
+	c.index = abortIndex
+}
+
+// Abort the chain and write the status code.
+func (c *Context) AbortWithStatus(code int) {
+	c.Status(code)
+	c.Abort()
+}
+
+// Is the chain aborted.
+func (c *Context) IsAborted() bool {
+	return c.index >= abortIndex
+}
+
+// Get the matched route.
+// Return nil, when the request matches no route.
+func (c *Context) Route() *Route {
+	return c.route
+}
+
+// Get the metadata of the matched route by key, including the inherited
+// ones. It is the routing Result.Meta, which is resolved when the Mux starts,
+// or the metadata of the groups when a fallback handles the request.
+// Return false, when the key is not set or no route is matched.
+func (c *Context) Metadata(key string) (interface{}, bool) {
+	return c.meta.Get(key)
+}
+
+// Get the first value of the path param.
+// Return empty string, when the param doesn't exist.
+func (c *Context) Param(name string) string {
+	if values := c.ParamValues(name); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Get all the values of the path param.
+func (c *Context) ParamValues(name string) []string {
+	if c.Result == nil || c.Result.Params == nil {
+		return nil
+	}
+	return c.Result.Params[name]
+}
+
+// Get the first value of the query param.
+// Return empty string, when the param doesn't exist.
+func (c *Context) Query(name string) string {
+	return c.QueryValues().Get(name)
+}
+
+// Get the parsed query of the request url.
+func (c *Context) QueryValues() url.Values {
+	if c.query == nil {
+		c.query = c.Request.URL.Query()
+	}
+	return c.query
+}
+
+// Get the request header value.
+func (c *Context) Header(name string) string {
+	return c.Request.Header.Get(name)
+}
+
+// Get the request cookie value.
+// Return empty string, when the cookie doesn't exist.
+func (c *Context) Cookie(name string) string {
+	cookie, err := c.Request.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// Get the client IP. The first address of "X-Forwarded-For" header or the
+// "X-Real-Ip" header is used, then the remote address of the request.
+// The headers can be forged, unless they are set by a trusted proxy.
+func (c *Context) ClientIP() string {
+	if forwarded := c.Header("X-Forwarded-For"); forwarded != "" {
+		if ip := strings.TrimSpace(strings.Split(forwarded, ",")[0]); ip != "" {
+			return ip
+		}
+	}
+	if ip := strings.TrimSpace(c.Header("X-Real-Ip")); ip != "" {
+		return ip
+	}
+
+	host, _, err := net.SplitHostPort(strings.TrimSpace(c.Request.RemoteAddr))
+	if err != nil {
+		return c.Request.RemoteAddr
+	}
+	return host
+}
+
+// Store the value by key for current request.
+func (c *Context) Set(key string, value interface{}) {
+	if c.keys == nil {
+		c.keys = make(map[string]interface{})
+	}
+	c.keys[key] = value
+}
+
+// Get the value stored by key.
+func (c *Context) Get(key string) (value interface{}, ok bool) {
+	value, ok = c.keys[key]
+	return
+}
+
+// Write the response status code.
+func (c *Context) Status(code int) {
+	c.Response.WriteHeader(code)
+}
+
+// Set the response header value.
+func (c *Context) SetHeader(name string, value string) {
+	c.Response.Header().Set(name, value)
+}
+
+// Add the cookie to the response.
+func (c *Context) SetCookie(cookie *http.Cookie) {
+	http.SetCookie(c.Response, cookie)
+}
+
+// Call the func before the response header is written, so it can still set
+// the headers and cookies, such as saving the session. The funcs are called
+// in adding order, and they are not called if the header is never written.
+func (c *Context) BeforeWrite(f func()) {
+	c.writer.before = append(c.writer.before, f)
+}
+
+// Redirect the request to the url with the status code.
+func (c *Context) Redirect(code int, url string) {
+	http.Redirect(c.Response, c.Request, url, code)
+}
+
+// Reply the request with not found error.
+// The fallback and NotFound handler of the Mux handles it.
+func (c *Context) NotFound() {
+	c.mux.notFound(c)
+}
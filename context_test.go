@@ -0,0 +1,101 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContextAccessors(t *testing.T) {
+	mux := NewMux("myMux")
+	mux.Add([]string{"GET"}, "/users/(id)/page(num)", func(c *Context) {
+		assertTrue(c.Param("id") == "1", "case1", t)
+		assertTrue(c.Param("num") == "2", "case1", t)
+		assertTrue(c.Param("none") == "", "case1", t)
+		assertTrue(len(c.ParamValues("id")) == 1, "case1", t)
+
+		assertTrue(c.Query("q") == "light", "case2", t)
+		assertTrue(len(c.QueryValues()["tag"]) == 2, "case2", t)
+
+		assertTrue(c.Header("X-Name") == "li", "case3", t)
+		assertTrue(c.Cookie("sid") == "abc", "case3", t)
+		assertTrue(c.Cookie("none") == "", "case3", t)
+
+		_, ok := c.Get("key")
+		assertFalse(ok, "case4", t)
+		c.Set("key", 1)
+		v, ok := c.Get("key")
+		assertTrue(ok && v.(int) == 1, "case4", t)
+
+		c.SetHeader("X-Result", "ok")
+		c.SetCookie(&http.Cookie{Name: "sid", Value: "xyz"})
+		c.Status(http.StatusCreated)
+	})
+	mux.Start()
+
+	r := httptest.NewRequest("GET", "/users/1/page2?q=light&tag=a&tag=b", nil)
+	r.Header.Set("X-Name", "li")
+	r.AddCookie(&http.Cookie{Name: "sid", Value: "abc"})
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+
+	assertTrue(w.Code == http.StatusCreated, "case5", t)
+	assertTrue(w.Header().Get("X-Result") == "ok", "case5", t)
+	assertTrue(w.Header().Get("Set-Cookie") == "sid=xyz", "case5", t)
+}
+
+func TestContextAbort(t *testing.T) {
+	var steps []string
+	mux := NewMux("myMux")
+	mux.Use(func(c *Context) {
+		steps = append(steps, "m1")
+		c.Next()
+		steps = append(steps, "m1 end")
+		assertTrue(c.IsAborted(), "case1", t)
+		assertTrue(c.Response.Status() == http.StatusForbidden, "case1", t)
+	}, func(c *Context) {
+		steps = append(steps, "m2")
+		c.AbortWithStatus(http.StatusForbidden)
+	})
+	mux.Add([]string{"GET"}, "/home", func(c *Context) {
+		steps = append(steps, "handler")
+	})
+	mux.Start()
+
+	w1 := serve(mux, "GET", "/home")
+	assertTrue(w1.Code == http.StatusForbidden, "case2", t)
+	assertTrue(len(steps) == 3 && steps[2] == "m1 end", "case2", t)
+}
+
+func TestContextPool(t *testing.T) {
+	mux := NewMux("myMux")
+	mux.Add([]string{"GET"}, "/home", func(c *Context) {
+		_, ok := c.Get("key")
+		assertFalse(ok, "case1", t)
+		assertFalse(c.Response.Written(), "case1", t)
+		c.Set("key", "value")
+		c.Response.Write([]byte("home"))
+		assertTrue(c.Response.Size() == 4, "case1", t)
+	})
+	mux.Start()
+
+	for i := 0; i < 3; i++ {
+		serve(mux, "GET", "/home")
+	}
+}
+
+func TestContextRedirect(t *testing.T) {
+	mux := NewMux("myMux")
+	mux.Add([]string{"GET"}, "/old", func(c *Context) {
+		c.Redirect(http.StatusFound, "/new")
+	})
+	mux.Start()
+
+	w1 := serve(mux, "GET", "/old")
+	assertTrue(w1.Code == http.StatusFound, "case1", t)
+	assertTrue(w1.Header().Get("Location") == "/new", "case1", t)
+}
@@ -0,0 +1,38 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"github.com/arging/utils/errors"
+	"github.com/uestcer/light/securecookie"
+	"net/http"
+)
+
+// Add the cookie to the response, the value is signed and encrypted by the
+// codecs, see securecookie. Use securecookie.Codecs{codec} for a single codec.
+func (c *Context) SetSecureCookie(codecs securecookie.Codecs, cookie *http.Cookie) error {
+	value, err := codecs.Encode(cookie.Name, []byte(cookie.Value))
+	if err != nil {
+		return errors.Wrapf(err, "set secure cookie error: %s.", cookie.Name)
+	}
+	secure := *cookie
+	secure.Value = value
+	http.SetCookie(c.Response, &secure)
+	return nil
+}
+
+// Get the value of the secure cookie set by SetSecureCookie.
+// Return error, when the cookie is missing, forged or expired.
+func (c *Context) SecureCookie(codecs securecookie.Codecs, name string) (string, error) {
+	cookie, err := c.Request.Cookie(name)
+	if err != nil {
+		return "", errors.Wrapf(err, "get secure cookie error: %s.", name)
+	}
+	value, err := codecs.Decode(name, cookie.Value)
+	if err != nil {
+		return "", errors.Wrapf(err, "get secure cookie error: %s.", name)
+	}
+	return string(value), nil
+}
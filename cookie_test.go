@@ -0,0 +1,48 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"github.com/uestcer/light/securecookie"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSecureCookie(t *testing.T) {
+	codecs, _ := securecookie.CodecsFromPairs(securecookie.GenerateKey(32), securecookie.GenerateKey(32))
+	mux := NewMux("myMux")
+	mux.Add([]string{"GET"}, "/set", func(c *Context) {
+		c.SetSecureCookie(codecs, &http.Cookie{Name: "flash", Value: "saved", Path: "/"})
+	})
+	mux.Add([]string{"GET"}, "/get", func(c *Context) {
+		value, err := c.SecureCookie(codecs, "flash")
+		if err != nil {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		c.Text(http.StatusOK, value)
+	})
+	mux.Start()
+
+	w := serve(mux, "GET", "/set")
+	cookie := w.Result().Cookies()[0]
+	assertTrue(cookie.Value != "saved" && cookie.Path == "/", "case1", t)
+
+	r := httptest.NewRequest("GET", "/get", nil)
+	r.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	assertTrue(w.Body.String() == "saved", "case2", t)
+
+	r = httptest.NewRequest("GET", "/get", nil)
+	r.AddCookie(&http.Cookie{Name: "flash", Value: "saved"})
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	assertTrue(w.Code == http.StatusBadRequest, "case3", t)
+
+	w = serve(mux, "GET", "/get")
+	assertTrue(w.Code == http.StatusBadRequest, "case4", t)
+}
@@ -0,0 +1,149 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"github.com/arging/utils/errors"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig is the config for CORS middleware.
+type CORSConfig struct {
+
+	// The allowed origins, such as "https://example.com". The pattern with
+	// "*" matches the subdomains, such as "https://*.example.com", and "*"
+	// allows all origins.
+	AllowOrigins []string
+
+	// Allow the origin by func, it is checked when AllowOrigins doesn't
+	// allow the origin.
+	AllowOriginFunc func(origin string) bool
+
+	// The allowed request headers of preflight.
+	// Default is the headers requested by the preflight.
+	AllowHeaders []string
+
+	// The response headers which the scripts can read.
+	ExposeHeaders []string
+
+	// Allow the cookies and the authorization headers.
+	// It can't be used with the "*" origin, which allows every site to send
+	// the credentials of the users.
+	AllowCredentials bool
+
+	// How long the preflight result can be cached, zero is not set.
+	MaxAge time.Duration
+}
+
+// CORS handles the cross-origin requests, see the Fetch Standard.
+// It should be global middleware, so the preflights are handled even no
+// OPTIONS route is added, and run before the auth middleware, since the
+// preflights have no credentials:
+//
+//	mux.Use(light.CORS(light.CORSConfig{AllowOrigins: []string{"https://*.example.com"}}))
+//
+// The preflight is replied 204 with the methods of the routes which match the
+// url, and it is replied 403 if the origin isn't allowed. When no route
+// matches the url, the preflight goes on to be not found.
+// The other requests are handled as usual, the CORS headers are only set for
+// the allowed origins.
+//
+// It panics, if the "*" origin is allowed with the credentials.
+func CORS(config CORSConfig) HandlerFunc {
+	allowAll := false
+	origins := make([]string, 0, len(config.AllowOrigins))
+	for _, origin := range config.AllowOrigins {
+		if origin == "*" {
+			allowAll = true
+		}
+		origins = append(origins, strings.ToLower(strings.TrimRight(origin, pathSep)))
+	}
+	if allowAll && config.AllowCredentials {
+		panic(errors.New("cors error, the \"*\" origin can't allow credentials."))
+	}
+	allowed := func(origin string) bool {
+		if allowAll {
+			return true
+		}
+		lower := strings.ToLower(origin)
+		for _, pattern := range origins {
+			if ok, _ := path.Match(pattern, lower); ok {
+				return true
+			}
+		}
+		return config.AllowOriginFunc != nil && config.AllowOriginFunc(origin)
+	}
+
+	allowHeaders := strings.Join(config.AllowHeaders, ", ")
+	exposeHeaders := strings.Join(config.ExposeHeaders, ", ")
+	maxAge := ""
+	if config.MaxAge > 0 {
+		maxAge = strconv.Itoa(int(config.MaxAge / time.Second))
+	}
+
+	return func(c *Context) {
+		// The response depends on the origin, even it is not a CORS request.
+		header := c.Response.Header()
+		if !allowAll {
+			header.Add("Vary", "Origin")
+		}
+		origin := c.Header("Origin")
+		if origin == "" {
+			return
+		}
+
+		preflight := c.Request.Method == "OPTIONS" && c.Header("Access-Control-Request-Method") != ""
+		if !allowed(origin) {
+			if preflight {
+				c.Error(NewHTTPError(http.StatusForbidden, "CORS origin not allowed"))
+			}
+			return
+		}
+
+		if allowAll {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if config.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if !preflight {
+			if exposeHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", exposeHeaders)
+			}
+			return
+		}
+
+		methods := c.mux.allowedMethods(c.Request.URL.Path)
+		if len(methods) == 0 {
+			return
+		}
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		header.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+		if allowHeaders != "" {
+			header.Set("Access-Control-Allow-Headers", allowHeaders)
+		} else if requested := c.Header("Access-Control-Request-Headers"); requested != "" {
+			header.Set("Access-Control-Allow-Headers", requested)
+		}
+		if maxAge != "" {
+			header.Set("Access-Control-Max-Age", maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// Get the methods of the routes which match the url, see Router.Methods.
+func (m *Mux) allowedMethods(url string) []string {
+	if m.FormatSuffix {
+		url, _ = splitFormat(url)
+	}
+	return m.router.Methods(url)
+}
@@ -0,0 +1,110 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func corsMux(config CORSConfig) *Mux {
+	mux := NewMux("myMux")
+	mux.FormatSuffix = true
+	mux.Use(CORS(config), APIKeyAuth(APIKeyConfig{Verify: func(key string) *Principal {
+		return &Principal{ID: key}
+	}}))
+	mux.Add([]string{"GET", "POST"}, "/users", urlHandler)
+	mux.Add([]string{"PUT", "DELETE"}, "/items/(id)", urlHandler)
+	mux.Start()
+	return mux
+}
+
+func TestCORSPreflight(t *testing.T) {
+	mux := corsMux(CORSConfig{
+		AllowOrigins: []string{"https://example.com", "https://*.example.org/"},
+		MaxAge:       time.Hour,
+	})
+
+	w := serve(mux, "OPTIONS", "/items/1", "Origin", "https://example.com",
+		"Access-Control-Request-Method", "PUT", "Access-Control-Request-Headers", "X-API-Key")
+	h := w.Header()
+	assertTrue(w.Code == http.StatusNoContent, "case1", t)
+	assertTrue(h.Get("Access-Control-Allow-Origin") == "https://example.com", "case1", t)
+	assertTrue(h.Get("Access-Control-Allow-Methods") == "DELETE, PUT", "case1", t)
+	assertTrue(h.Get("Access-Control-Allow-Headers") == "X-API-Key", "case1", t)
+	assertTrue(h.Get("Access-Control-Max-Age") == "3600", "case1", t)
+	assertTrue(h.Get("Access-Control-Allow-Credentials") == "", "case1", t)
+	assertTrue(strings.Join(h.Values("Vary"), ",") ==
+		"Origin,Access-Control-Request-Method,Access-Control-Request-Headers", "case1", t)
+
+	// the pattern and the format suffix
+	w = serve(mux, "OPTIONS", "/users.json", "Origin", "https://api.EXAMPLE.org",
+		"Access-Control-Request-Method", "POST")
+	assertTrue(w.Code == http.StatusNoContent, "case2", t)
+	assertTrue(w.Header().Get("Access-Control-Allow-Methods") == "GET, POST", "case2", t)
+	assertTrue(w.Header().Get("Access-Control-Allow-Headers") == "", "case2", t)
+
+	w = serve(mux, "OPTIONS", "/users", "Origin", "https://evil.com",
+		"Access-Control-Request-Method", "POST")
+	assertTrue(w.Code == http.StatusForbidden, "case3", t)
+	assertTrue(w.Header().Get("Access-Control-Allow-Origin") == "", "case3", t)
+	w = serve(mux, "OPTIONS", "/users", "Origin", "https://a.b.example.com",
+		"Access-Control-Request-Method", "POST")
+	assertTrue(w.Code == http.StatusForbidden, "case3", t)
+
+	// no route for the url
+	w = serve(mux, "OPTIONS", "/none", "Origin", "https://example.com",
+		"Access-Control-Request-Method", "GET")
+	assertTrue(w.Code == http.StatusUnauthorized, "case4", t)
+
+	// not a preflight
+	w = serve(mux, "OPTIONS", "/users", "Origin", "https://example.com")
+	assertTrue(w.Code == http.StatusUnauthorized, "case5", t)
+}
+
+func TestCORSRequest(t *testing.T) {
+	mux := corsMux(CORSConfig{
+		AllowOrigins:     []string{"https://example.com"},
+		AllowOriginFunc:  func(origin string) bool { return strings.HasSuffix(origin, ".local") },
+		ExposeHeaders:    []string{"X-Request-Id", "X-Total"},
+		AllowCredentials: true,
+	})
+
+	w := serve(mux, "GET", "/users", "Origin", "https://example.com", "X-API-Key", "k")
+	h := w.Header()
+	assertTrue(w.Code == http.StatusOK && w.Body.String() == "/users", "case1", t)
+	assertTrue(h.Get("Access-Control-Allow-Origin") == "https://example.com", "case1", t)
+	assertTrue(h.Get("Access-Control-Allow-Credentials") == "true", "case1", t)
+	assertTrue(h.Get("Access-Control-Expose-Headers") == "X-Request-Id, X-Total", "case1", t)
+	assertTrue(h.Get("Vary") == "Origin" && h.Get("Access-Control-Allow-Methods") == "", "case1", t)
+
+	w = serve(mux, "GET", "/users", "Origin", "http://dev.local", "X-API-Key", "k")
+	assertTrue(w.Header().Get("Access-Control-Allow-Origin") == "http://dev.local", "case2", t)
+
+	// the disallowed origin is served without CORS headers
+	w = serve(mux, "GET", "/users", "Origin", "https://evil.com", "X-API-Key", "k")
+	assertTrue(w.Code == http.StatusOK && w.Header().Get("Access-Control-Allow-Origin") == "", "case3", t)
+	w = serve(mux, "GET", "/users", "X-API-Key", "k")
+	assertTrue(w.Code == http.StatusOK && w.Header().Get("Vary") == "Origin", "case3", t)
+
+	// all origins
+	mux = corsMux(CORSConfig{AllowOrigins: []string{"*"}})
+	w = serve(mux, "GET", "/users", "Origin", "https://any.com", "X-API-Key", "k")
+	assertTrue(w.Header().Get("Access-Control-Allow-Origin") == "*", "case4", t)
+	assertTrue(w.Header().Get("Vary") == "", "case4", t)
+	w = serve(mux, "GET", "/users", "X-API-Key", "k")
+	assertTrue(w.Header().Get("Vary") == "", "case4", t)
+
+	// all origins can't allow credentials
+	panics := func(config CORSConfig) (p bool) {
+		defer func() { p = recover() != nil }()
+		CORS(config)
+		return
+	}
+	assertTrue(panics(CORSConfig{AllowOrigins: []string{"*"}, AllowCredentials: true}), "case5", t)
+	assertTrue(!panics(CORSConfig{AllowOrigins: []string{"https://*.example.com"}, AllowCredentials: true}), "case5", t)
+}
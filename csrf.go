@@ -0,0 +1,188 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"github.com/arging/utils/errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Modes of CSRF protection.
+const (
+	CSRFToken  = "token"  // Check the per-session token
+	CSRFOrigin = "origin" // Check the Origin or Referer header
+)
+
+// The metadata key to skip CSRF check, such as for webhooks:
+//
+//	mux.Add([]string{"POST"}, "/hooks/github", hook).Meta(light.MetaCSRFExempt, true)
+const MetaCSRFExempt = "csrf.exempt"
+
+// The session key of CSRF token.
+const csrfSessionKey = "light.csrf"
+
+// The length of CSRF token.
+const csrfTokenLen = 32
+
+// The safe methods, they are not checked.
+var safeMethods = map[string]bool{"GET": true, "HEAD": true, "OPTIONS": true, "TRACE": true}
+
+// CSRFConfig is the config for CSRF middleware.
+type CSRFConfig struct {
+	Mode       string // CSRFToken or CSRFOrigin, default is CSRFToken
+	FieldName  string // The form field of token, default is "csrf_token"
+	HeaderName string // The header of token, default is "X-CSRF-Token"
+
+	// The origins trusted besides the request host in CSRFOrigin mode,
+	// such as "https://example.com".
+	TrustedOrigins []string
+}
+
+// The context key of CSRF config, it is used by CSRFField.
+const csrfKey = "light.csrfConfig"
+
+// CSRF protects the requests of unsafe methods, which are all methods except
+// GET, HEAD, OPTIONS and TRACE. The request is replied 403, if the check fails.
+//
+// In CSRFToken mode, the token is stored in the session, so the Sessions
+// middleware must run before. The token is sent back by the form field or
+// the header, and the hidden field is emitted by Context.CSRFField:
+//
+//	c.HTML(http.StatusOK, "users/edit", map[string]interface{}{
+//		"csrfField": c.CSRFField(), // <form method="post">{{.csrfField}} ...
+//	})
+//
+// In CSRFOrigin mode, the Origin header, or the Referer header if no Origin,
+// must be the request host or a trusted origin.
+//
+// The routes with metadata MetaCSRFExempt are not checked.
+func CSRF(config CSRFConfig) HandlerFunc {
+	if config.Mode == "" {
+		config.Mode = CSRFToken
+	}
+	if config.FieldName == "" {
+		config.FieldName = "csrf_token"
+	}
+	if config.HeaderName == "" {
+		config.HeaderName = "X-CSRF-Token"
+	}
+	trusted := make(map[string]bool, len(config.TrustedOrigins))
+	for _, origin := range config.TrustedOrigins {
+		trusted[strings.ToLower(strings.TrimRight(origin, pathSep))] = true
+	}
+
+	return func(c *Context) {
+		c.Set(csrfKey, &config)
+		c.hideHeader(config.HeaderName)
+		if safeMethods[c.Request.Method] {
+			return
+		}
+		if exempt, _ := c.Metadata(MetaCSRFExempt); exempt == true {
+			return
+		}
+
+		if config.Mode == CSRFOrigin {
+			if !c.checkOrigin(trusted) {
+				c.Error(NewHTTPError(http.StatusForbidden, "CSRF origin check failed"))
+			}
+			return
+		}
+
+		if c.Session() == nil {
+			c.Error(errors.New("csrf error, the Sessions middleware is required."))
+			return
+		}
+		token := c.Request.Header.Get(config.HeaderName)
+		if token == "" {
+			token = c.Request.PostFormValue(config.FieldName)
+		}
+		if !c.checkCSRFToken(token) {
+			c.Error(NewHTTPError(http.StatusForbidden, "CSRF token invalid"))
+		}
+	}
+}
+
+// Is the Origin or Referer header trusted.
+func (c *Context) checkOrigin(trusted map[string]bool) bool {
+	origin := c.Request.Header.Get("Origin")
+	if origin == "" || origin == "null" {
+		origin = c.Request.Referer()
+	}
+	u, err := url.Parse(origin)
+	if origin == "" || err != nil || u.Host == "" {
+		return false
+	}
+	if strings.EqualFold(u.Host, c.Request.Host) {
+		return true
+	}
+	return trusted[strings.ToLower(u.Scheme+"://"+u.Host)]
+}
+
+// Get the CSRF token of the session, it is created if the session has none.
+// The token is masked by a random pad for each call, so it differs in each
+// response. Return empty string, when the CSRF or Sessions middleware is not
+// used.
+func (c *Context) CSRFToken() string {
+	s := c.Session()
+	if s == nil {
+		return ""
+	}
+	secret, _ := s.Get(csrfSessionKey)
+	raw, _ := secret.(string)
+	key, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil || len(key) != csrfTokenLen {
+		key = make([]byte, csrfTokenLen)
+		rand.Read(key)
+		s.Set(csrfSessionKey, base64.RawURLEncoding.EncodeToString(key))
+	}
+
+	pad := make([]byte, csrfTokenLen)
+	rand.Read(pad)
+	masked := make([]byte, csrfTokenLen*2)
+	copy(masked, pad)
+	for i := range key {
+		masked[csrfTokenLen+i] = pad[i] ^ key[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(masked)
+}
+
+// Get the hidden input of the CSRF token for HTML forms.
+// Return empty, when the CSRF or Sessions middleware is not used.
+func (c *Context) CSRFField() template.HTML {
+	v, ok := c.Get(csrfKey)
+	token := c.CSRFToken()
+	if !ok || token == "" {
+		return ""
+	}
+	name := v.(*CSRFConfig).FieldName
+	return template.HTML(`<input type="hidden" name="` + template.HTMLEscapeString(name) +
+		`" value="` + token + `">`)
+}
+
+// Is the masked token the token of the session.
+func (c *Context) checkCSRFToken(token string) bool {
+	secret, _ := c.Session().Get(csrfSessionKey)
+	raw, _ := secret.(string)
+	key, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil || len(key) != csrfTokenLen {
+		return false
+	}
+	masked, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(masked) != csrfTokenLen*2 {
+		return false
+	}
+
+	unmasked := make([]byte, csrfTokenLen)
+	for i := range unmasked {
+		unmasked[i] = masked[i] ^ masked[csrfTokenLen+i]
+	}
+	return subtle.ConstantTimeCompare(unmasked, key) == 1
+}
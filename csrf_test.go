@@ -0,0 +1,111 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"github.com/uestcer/light/session"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func csrfMux(config CSRFConfig) *Mux {
+	mux := NewMux("myMux")
+	mux.Use(Sessions(SessionConfig{Store: session.NewMemoryStore(time.Hour)}), CSRF(config))
+	mux.Add([]string{"GET"}, "/form", func(c *Context) {
+		c.Data(http.StatusOK, MIMEHTML, []byte(c.CSRFField()))
+	})
+	mux.Add([]string{"POST"}, "/form", urlHandler)
+	mux.Add([]string{"POST"}, "/hooks/github", urlHandler).Meta(MetaCSRFExempt, true)
+	mux.Group("/api").Meta(MetaCSRFExempt, true).Add([]string{"DELETE"}, "/users", urlHandler)
+	mux.Start()
+	return mux
+}
+
+func postForm(mux *Mux, url string, form url.Values, cookie *http.Cookie, header ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("POST", url, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	return w
+}
+
+func TestCSRFToken(t *testing.T) {
+	mux := csrfMux(CSRFConfig{})
+
+	w, cookie := serveSession(mux, "/form", nil)
+	field := regexp.MustCompile(`^<input type="hidden" name="csrf_token" value="([\w-]+)">$`)
+	m := field.FindStringSubmatch(w.Body.String())
+	assertTrue(m != nil && cookie != nil, "case1", t)
+	token := m[1]
+
+	// the token is masked differently for each response
+	w, _ = serveSession(mux, "/form", cookie)
+	assertTrue(field.FindStringSubmatch(w.Body.String())[1] != token, "case2", t)
+
+	w = postForm(mux, "/form", url.Values{"csrf_token": {token}}, cookie)
+	assertTrue(w.Code == http.StatusOK && w.Body.String() == "/form", "case3", t)
+	w = postForm(mux, "/form", nil, cookie, "X-CSRF-Token", token)
+	assertTrue(w.Code == http.StatusOK, "case3", t)
+
+	w = postForm(mux, "/form", url.Values{"csrf_token": {"bad"}}, cookie)
+	assertTrue(w.Code == http.StatusForbidden, "case4", t)
+	w = postForm(mux, "/form", nil, cookie)
+	assertTrue(w.Code == http.StatusForbidden, "case4", t)
+	w = postForm(mux, "/form", url.Values{"csrf_token": {token}}, nil)
+	assertTrue(w.Code == http.StatusForbidden, "case4", t)
+
+	// the exempt routes
+	w = postForm(mux, "/hooks/github", nil, nil)
+	assertTrue(w.Code == http.StatusOK, "case5", t)
+	r := httptest.NewRequest("DELETE", "/api/users", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	assertTrue(w.Code == http.StatusOK, "case5", t)
+}
+
+func TestCSRFOrigin(t *testing.T) {
+	mux := csrfMux(CSRFConfig{Mode: CSRFOrigin, TrustedOrigins: []string{"https://app.example.com/"}})
+
+	w := postForm(mux, "/form", nil, nil, "Origin", "http://example.com")
+	assertTrue(w.Code == http.StatusOK, "case1", t)
+	w = postForm(mux, "/form", nil, nil, "Origin", "https://app.example.com")
+	assertTrue(w.Code == http.StatusOK, "case1", t)
+	w = postForm(mux, "/form", nil, nil, "Referer", "http://example.com/form")
+	assertTrue(w.Code == http.StatusOK, "case1", t)
+
+	w = postForm(mux, "/form", nil, nil, "Origin", "https://evil.com")
+	assertTrue(w.Code == http.StatusForbidden, "case2", t)
+	w = postForm(mux, "/form", nil, nil, "Origin", "http://app.example.com")
+	assertTrue(w.Code == http.StatusForbidden, "case2", t)
+	w = postForm(mux, "/form", nil, nil)
+	assertTrue(w.Code == http.StatusForbidden, "case2", t)
+}
+
+func TestCSRFNoSession(t *testing.T) {
+	mux := NewMux("myMux")
+	mux.Logger.SetOutput(new(strings.Builder))
+	mux.Use(CSRF(CSRFConfig{}))
+	mux.Add([]string{"POST"}, "/form", urlHandler)
+	mux.Add([]string{"GET"}, "/form", func(c *Context) {
+		c.Text(http.StatusOK, string(c.CSRFField())+c.CSRFToken())
+	})
+	mux.Start()
+
+	w := postForm(mux, "/form", nil, nil)
+	assertTrue(w.Code == http.StatusInternalServerError, "case1", t)
+	w = serve(mux, "GET", "/form")
+	assertTrue(w.Code == http.StatusOK && w.Body.Len() == 0, "case2", t)
+}
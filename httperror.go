@@ -0,0 +1,137 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"github.com/arging/utils/errors"
+	"net/http"
+)
+
+// HTTPError is the error replied to the client with the status code.
+// The Message and Details are public, they are sent to the client.
+// The wrapped cause is internal, it is logged but never sent.
+type HTTPError struct {
+	Code    int         // The status code
+	Message string      // The public message, default is the status text
+	Details interface{} // The public details, such as the field errors
+	Type    string      // The problem type URI for problem details
+
+	err   errors.Error // The error for logging, including the cause
+	cause error
+}
+
+// Create the HTTPError by the status code and public message.
+// If the message is empty, the status text is used.
+func NewHTTPError(code int, message string) *HTTPError {
+	if message == "" {
+		message = http.StatusText(code)
+	}
+	err := errors.Newf("http error %d: %s.", code, message)
+	return &HTTPError{Code: code, Message: message, err: err}
+}
+
+// Create the HTTPError wrapping the internal cause.
+// If the message is empty, the status text is used.
+func WrapHTTPError(cause error, code int, message string) *HTTPError {
+	if message == "" {
+		message = http.StatusText(code)
+	}
+	err := errors.Wrapf(cause, "http error %d: %s.", code, message)
+	return &HTTPError{Code: code, Message: message, err: err, cause: cause}
+}
+
+// Set the public details of the error, such as the field errors of 422.
+func (e *HTTPError) WithDetails(details interface{}) *HTTPError {
+	e.Details = details
+	return e
+}
+
+// Set the problem type URI of the error.
+func (e *HTTPError) WithType(uri string) *HTTPError {
+	e.Type = uri
+	return e
+}
+
+// Get the error message for logging, including the cause.
+func (e *HTTPError) Error() string {
+	return e.err.Error()
+}
+
+// Get the wrapped cause.
+func (e *HTTPError) Unwrap() error {
+	return e.cause
+}
+
+// Get the HTTPError in the error chain, the chain is walked by Unwrap.
+// Return false, when no HTTPError in the chain.
+func AsHTTPError(err error) (*HTTPError, bool) {
+	for err != nil {
+		if e, ok := err.(*HTTPError); ok {
+			return e, true
+		}
+		u, ok := err.(interface {
+			Unwrap() error
+		})
+		if !ok {
+			break
+		}
+		err = u.Unwrap()
+	}
+	return nil, false
+}
+
+// ErrorHandler replies the error to the client.
+type ErrorHandler func(c *Context, err error)
+
+// The error response body of DefaultErrorHandler.
+type errorBody struct {
+	Error   string      `json:"error"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// DefaultErrorHandler replies the HTTPError by its code and public message.
+// Other errors are replied as 500 Internal Server Error.
+// The internal errors, which are not HTTPError or the code is 5xx, are logged.
+//
+// The response is problem details of RFC 7807, if the ProblemDetails of the Mux
+// is set or the client accepts "application/problem+json". Otherwise, it is
+// JSON if the client prefers it, or plain text.
+func DefaultErrorHandler(c *Context, err error) {
+	e, ok := AsHTTPError(err)
+	if !ok {
+		e = NewHTTPError(http.StatusInternalServerError, "")
+	}
+	if !ok || e.Code >= http.StatusInternalServerError {
+		c.mux.Logger.Printf("%s %s error: %v", c.Request.Method, c.Request.URL.Path, err)
+	}
+
+	if c.Response.Written() {
+		return
+	}
+	if c.mux.ProblemDetails {
+		c.Problem(NewProblem(c, e))
+		return
+	}
+	switch c.Format(FormatText, FormatJSON, FormatProblem) {
+	case FormatProblem:
+		c.Problem(NewProblem(c, e))
+	case FormatJSON:
+		c.JSON(e.Code, &errorBody{e.Message, e.Details})
+	default:
+		c.Text(e.Code, e.Message)
+	}
+}
+
+// Reply the error by the error handler of the Mux, and abort the chain.
+// Return a *HTTPError for the client errors, such as:
+//
+//	if err := c.Bind(&user); err != nil {
+//		c.Error(light.WrapHTTPError(err, http.StatusBadRequest, "bad user"))
+//		return
+//	}
+func (c *Context) Error(err error) {
+	c.Abort()
+	c.mux.ErrorHandler(c, err)
+}
@@ -0,0 +1,81 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"bytes"
+	"github.com/arging/utils/errors"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHTTPError(t *testing.T) {
+	e1 := NewHTTPError(http.StatusNotFound, "")
+	assertTrue(e1.Code == 404 && e1.Message == "Not Found", "case1", t)
+	assertTrue(e1.Unwrap() == nil, "case1", t)
+
+	cause := errors.New("sql: no rows")
+	e2 := WrapHTTPError(cause, http.StatusNotFound, "user not found")
+	assertTrue(e2.Unwrap() == cause, "case2", t)
+	assertTrue(strings.Contains(e2.Error(), "sql: no rows"), "case2", t)
+
+	var err error = &wrapError{e2}
+	e3, ok := AsHTTPError(err)
+	assertTrue(ok && e3 == e2, "case3", t)
+	_, ok = AsHTTPError(cause)
+	assertFalse(ok, "case3", t)
+}
+
+type wrapError struct {
+	err error
+}
+
+func (e *wrapError) Error() string { return "wrap: " + e.err.Error() }
+func (e *wrapError) Unwrap() error { return e.err }
+
+func TestErrorHandler(t *testing.T) {
+	var logs bytes.Buffer
+	mux := NewMux("myMux")
+	mux.Logger = log.New(&logs, "", 0)
+	mux.Add([]string{"GET"}, "/users/(id)", func(c *Context) {
+		cause := errors.New("sql: no rows")
+		c.Error(WrapHTTPError(cause, http.StatusNotFound, "user not found"))
+	})
+	mux.Add([]string{"POST"}, "/users", func(c *Context) {
+		fields := map[string]string{"Name": "Name is required"}
+		c.Error(NewHTTPError(http.StatusUnprocessableEntity, "").WithDetails(fields))
+	})
+	mux.Add([]string{"GET"}, "/internal", func(c *Context) {
+		t.Error("aborted chain should not run")
+	}, func(c *Context) {
+		c.Error(errors.New("db password is wrong"))
+	})
+	mux.Start()
+
+	w1 := serve(mux, "GET", "/users/1")
+	assertTrue(w1.Code == http.StatusNotFound && w1.Body.String() == "user not found", "case1", t)
+	assertTrue(logs.Len() == 0, "case1", t)
+
+	w2 := serve(mux, "POST", "/users", "Accept", "application/json")
+	assertTrue(w2.Code == http.StatusUnprocessableEntity, "case2", t)
+	assertTrue(w2.Body.String() == `{"error":"Unprocessable Entity","details":{"Name":"Name is required"}}`, "case2", t)
+
+	w3 := serve(mux, "GET", "/internal")
+	assertTrue(w3.Code == http.StatusInternalServerError, "case3", t)
+	assertFalse(strings.Contains(w3.Body.String(), "password"), "case3", t)
+	assertTrue(strings.Contains(logs.String(), "db password is wrong"), "case3", t)
+
+	w4 := serve(mux, "GET", "/none", "Accept", "application/json")
+	assertTrue(w4.Code == http.StatusNotFound && w4.Body.String() == `{"error":"Not Found"}`, "case4", t)
+
+	// replace the handler
+	mux.ErrorHandler = func(c *Context, err error) {
+		c.Text(http.StatusTeapot, "custom")
+	}
+	w5 := serve(mux, "GET", "/users/1")
+	assertTrue(w5.Code == http.StatusTeapot && w5.Body.String() == "custom", "case5", t)
+}
@@ -0,0 +1,260 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+// Package jwt verifies and signs the JSON Web Tokens of RFC 7519.
+// Only the HS256 and RS256 algorithms are supported:
+//
+//	parser, err := jwt.NewParser(key, nil)
+//	parser.Audience = "api"
+//	claims, err := parser.Parse(token)
+//	user := claims.Subject()
+//
+// The algorithm of the token must match the configured key, so the token
+// signed by HS256 with the RSA public key is rejected.
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/arging/utils/errors"
+	"strings"
+	"time"
+)
+
+// The signing algorithms.
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+)
+
+// The min length of HS256 key, the shorter keys are rejected.
+const MinHMACKeyLen = 32
+
+// Claims is the payload of the token.
+type Claims map[string]interface{}
+
+// Get the string claim by name.
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+// Get the strings claim by name, a single string is also accepted.
+func (c Claims) Strings(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		strs := make([]string, 0, len(v))
+		for _, elem := range v {
+			if s, ok := elem.(string); ok {
+				strs = append(strs, s)
+			}
+		}
+		return strs
+	case []string:
+		return v
+	}
+	return nil
+}
+
+// Get the time claim by name, such as "exp".
+// Return false, when the claim is missing or not a number.
+func (c Claims) Time(name string) (time.Time, bool) {
+	switch v := c[name].(type) {
+	case float64:
+		return time.Unix(int64(v), 0), true
+	case json.Number:
+		n, err := v.Int64()
+		return time.Unix(n, 0), err == nil
+	case int64:
+		return time.Unix(v, 0), true
+	case int:
+		return time.Unix(int64(v), 0), true
+	}
+	return time.Time{}, false
+}
+
+// Get the subject of the token, the "sub" claim.
+func (c Claims) Subject() string {
+	return c.String("sub")
+}
+
+// Get the audience of the token, the "aud" claim.
+func (c Claims) Audience() []string {
+	return c.Strings("aud")
+}
+
+// Parser verifies the tokens.
+type Parser struct {
+	HMACKey []byte         // The key for HS256, empty to reject HS256
+	RSAKey  *rsa.PublicKey // The key for RS256, nil to reject RS256
+
+	Audience string        // The required audience, empty to skip the check
+	Issuer   string        // The required issuer, empty to skip the check
+	Leeway   time.Duration // The allowed clock skew for "exp" and "nbf"
+
+	now func() time.Time
+}
+
+// Create the parser by the keys, one of them is required. The HMAC key
+// must be at least MinHMACKeyLen bytes, if it is set.
+func NewParser(hmacKey []byte, rsaKey *rsa.PublicKey) (*Parser, errors.Error) {
+	if hmacKey == nil && rsaKey == nil {
+		return nil, errors.New("jwt error, no key.")
+	}
+	if hmacKey != nil && len(hmacKey) < MinHMACKeyLen {
+		return nil, errors.Newf("jwt error, HMAC key is shorter than %d bytes.", MinHMACKeyLen)
+	}
+	return &Parser{HMACKey: hmacKey, RSAKey: rsaKey}, nil
+}
+
+// Defined for the token header.
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// Parse the token and verify it. The signature, the "exp" and "nbf" claims,
+// and the audience and issuer if they are set, are checked.
+func (p *Parser) Parse(token string) (Claims, errors.Error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("jwt error, malformed token.")
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, errors.Wrapf(err, "jwt error, malformed header.")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.Wrapf(err, "jwt error, malformed signature.")
+	}
+	if err := p.verify(h.Alg, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, errors.Wrapf(err, "jwt error, malformed claims.")
+	}
+	if err := p.validate(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// Verify the signature by the algorithm.
+func (p *Parser) verify(alg string, signed string, sig []byte) errors.Error {
+	switch {
+	case alg == HS256 && len(p.HMACKey) >= MinHMACKeyLen:
+		mac := hmac.New(sha256.New, p.HMACKey)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return errors.New("jwt error, invalid signature.")
+		}
+	case alg == RS256 && p.RSAKey != nil:
+		digest := sha256.Sum256([]byte(signed))
+		if err := rsa.VerifyPKCS1v15(p.RSAKey, crypto.SHA256, digest[:], sig); err != nil {
+			return errors.Wrapf(err, "jwt error, invalid signature.")
+		}
+	default:
+		return errors.Newf("jwt error, unsupported algorithm: %s.", alg)
+	}
+	return nil
+}
+
+// Validate the claims.
+func (p *Parser) validate(claims Claims) errors.Error {
+	now := time.Now()
+	if p.now != nil {
+		now = p.now()
+	}
+
+	if exp, ok := claims.Time("exp"); ok && !now.Before(exp.Add(p.Leeway)) {
+		return errors.New("jwt error, token is expired.")
+	} else if !ok && claims["exp"] != nil {
+		return errors.New("jwt error, invalid exp claim.")
+	}
+	if nbf, ok := claims.Time("nbf"); ok && now.Add(p.Leeway).Before(nbf) {
+		return errors.New("jwt error, token is not valid yet.")
+	} else if !ok && claims["nbf"] != nil {
+		return errors.New("jwt error, invalid nbf claim.")
+	}
+
+	if p.Audience != "" && !contains(claims.Audience(), p.Audience) {
+		return errors.Newf("jwt error, audience mismatch: %s.", p.Audience)
+	}
+	if p.Issuer != "" && claims.String("iss") != p.Issuer {
+		return errors.Newf("jwt error, issuer mismatch: %s.", p.Issuer)
+	}
+	return nil
+}
+
+// Sign the claims by HS256 with the key.
+// The key must be at least MinHMACKeyLen bytes.
+func SignHS256(claims Claims, key []byte) (string, errors.Error) {
+	if len(key) < MinHMACKeyLen {
+		return "", errors.Newf("jwt sign error, HMAC key is shorter than %d bytes.", MinHMACKeyLen)
+	}
+	signed, err := encodeToken(HS256, claims)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Sign the claims by RS256 with the private key.
+func SignRS256(claims Claims, key *rsa.PrivateKey) (string, errors.Error) {
+	signed, err := encodeToken(RS256, claims)
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256([]byte(signed))
+	sig, e := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if e != nil {
+		return "", errors.Wrapf(e, "jwt sign error.")
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// Encode the header and claims of the token.
+func encodeToken(alg string, claims Claims) (string, errors.Error) {
+	h, err := json.Marshal(&header{Alg: alg, Typ: "JWT"})
+	if err != nil {
+		return "", errors.Wrapf(err, "jwt sign error.")
+	}
+	c, err := json.Marshal(claims)
+	if err != nil {
+		return "", errors.Wrapf(err, "jwt sign error, invalid claims.")
+	}
+	return base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c), nil
+}
+
+// Decode the base64 JSON segment.
+func decodeSegment(seg string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Is the string in the slice.
+func contains(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package jwt
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+func assertTrue(b bool, msg string, t *testing.T) {
+	if !b {
+		t.Error(msg)
+	}
+}
+
+func TestHS256(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	now := time.Now()
+	token, err := SignHS256(Claims{
+		"sub": "li", "aud": []string{"api", "web"}, "iss": "light",
+		"exp": now.Add(time.Hour).Unix(), "nbf": now.Unix(),
+	}, key)
+	assertTrue(err == nil && strings.Count(token, ".") == 2, "case1", t)
+
+	parser := &Parser{HMACKey: key, Audience: "api", Issuer: "light"}
+	claims, err := parser.Parse(token)
+	assertTrue(err == nil && claims.Subject() == "li", "case2", t)
+	assertTrue(len(claims.Audience()) == 2, "case2", t)
+	exp, ok := claims.Time("exp")
+	assertTrue(ok && exp.Unix() == now.Add(time.Hour).Unix(), "case2", t)
+
+	invalids := []*Parser{
+		{HMACKey: []byte("other")},
+		{HMACKey: key[:MinHMACKeyLen-1]},
+		{HMACKey: key, Audience: "admin"},
+		{HMACKey: key, Issuer: "other"},
+		{HMACKey: key, now: func() time.Time { return now.Add(2 * time.Hour) }},
+		{HMACKey: key, now: func() time.Time { return now.Add(-time.Minute) }},
+		{RSAKey: &rsa.PublicKey{}},
+	}
+	for i, p := range invalids {
+		if _, err := p.Parse(token); err == nil {
+			t.Errorf("case3 %d", i)
+		}
+	}
+
+	// leeway
+	parser = &Parser{HMACKey: key, Leeway: 2 * time.Minute, now: func() time.Time { return now.Add(-time.Minute) }}
+	_, err = parser.Parse(token)
+	assertTrue(err == nil, "case4", t)
+
+	// malformed
+	for _, bad := range []string{"", "a.b", "a.b.c", token + "x", strings.Replace(token, ".", ".x", 1)} {
+		_, err := (&Parser{HMACKey: key}).Parse(bad)
+		assertTrue(err != nil, "case5", t)
+	}
+	token, _ = SignHS256(Claims{"exp": "tomorrow"}, key)
+	_, err = (&Parser{HMACKey: key}).Parse(token)
+	assertTrue(err != nil, "case5", t)
+}
+
+func TestRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := SignRS256(Claims{"sub": "li", "aud": "api"}, key)
+	assertTrue(err == nil, "case1", t)
+
+	claims, err := (&Parser{RSAKey: &key.PublicKey, Audience: "api"}).Parse(token)
+	assertTrue(err == nil && claims.Subject() == "li", "case1", t)
+
+	// the RS256 token is rejected without RSA key
+	_, err = (&Parser{HMACKey: []byte("0123456789abcdef0123456789abcdef")}).Parse(token)
+	assertTrue(err != nil, "case2", t)
+
+	// the HS256 token signed by the public key is rejected
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	_, err = (&Parser{RSAKey: &other.PublicKey}).Parse(token)
+	assertTrue(err != nil, "case3", t)
+	forged, _ := SignHS256(Claims{"sub": "admin"}, key.PublicKey.N.Bytes())
+	_, err = (&Parser{RSAKey: &key.PublicKey}).Parse(forged)
+	assertTrue(err != nil, "case3", t)
+
+	// alg none
+	parts := strings.Split(token, ".")
+	_, err = (&Parser{RSAKey: &key.PublicKey}).Parse("eyJhbGciOiJub25lIn0." + parts[1] + ".")
+	assertTrue(err != nil, "case4", t)
+}
+
+func TestHMACKey(t *testing.T) {
+	_, err := NewParser(nil, nil)
+	assertTrue(err != nil, "case1", t)
+	_, err = NewParser([]byte{}, nil)
+	assertTrue(err != nil, "case1", t)
+	_, err = NewParser([]byte("short"), nil)
+	assertTrue(err != nil, "case1", t)
+	p, err := NewParser(make([]byte, MinHMACKeyLen), nil)
+	assertTrue(err == nil && p != nil, "case1", t)
+
+	// the token signed with an empty key is rejected
+	_, err = SignHS256(Claims{"sub": "attacker"}, []byte{})
+	assertTrue(err != nil, "case2", t)
+	mac := hmac.New(sha256.New, []byte{})
+	signed := "eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiJhdHRhY2tlciJ9"
+	mac.Write([]byte(signed))
+	token := signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	_, err = (&Parser{HMACKey: []byte{}}).Parse(token)
+	assertTrue(err != nil, "case2", t)
+}
+
+func TestClaims(t *testing.T) {
+	claims := Claims{"a": "x", "b": []interface{}{"y", 1, "z"}, "n": 12.0}
+	assertTrue(claims.String("a") == "x" && claims.String("n") == "", "case1", t)
+	assertTrue(strings.Join(claims.Strings("b"), ",") == "y,z", "case2", t)
+	assertTrue(claims.Strings("n") == nil, "case2", t)
+	tm, ok := claims.Time("n")
+	assertTrue(ok && tm.Unix() == 12, "case3", t)
+	_, ok = claims.Time("a")
+	assertTrue(!ok, "case3", t)
+}
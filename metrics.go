@@ -0,0 +1,276 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The content type of Prometheus text exposition format.
+const MIMEPrometheus = "text/plain; version=0.0.4; charset=utf-8"
+
+// The route label of the requests which match no route.
+const unmatchedRoute = "unmatched"
+
+// Default buckets of the request duration histogram, in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// The method labels, other methods are labeled "OTHER" to bound the cardinality.
+var metricMethods = map[string]bool{
+	"GET": true, "HEAD": true, "POST": true, "PUT": true, "PATCH": true,
+	"DELETE": true, "OPTIONS": true, "CONNECT": true, "TRACE": true,
+}
+
+// Metrics collects the request metrics labeled by route pattern, such as
+// "/users/(id)", rather than the raw url, so the label cardinality is bounded.
+// It is exposed in Prometheus text format without any client library:
+//
+//	metrics := light.NewMetrics("myapp")
+//	mux.Use(metrics.Middleware())
+//	mux.Add([]string{"GET"}, "/metrics", metrics.Handler())
+type Metrics struct {
+	namespace string
+	buckets   []float64
+
+	inFlight         int64 // accessed atomically
+	notFound         uint64
+	methodNotAllowed uint64
+
+	mu        sync.Mutex
+	requests  map[requestLabels]uint64
+	durations map[durationLabels]*histogram
+}
+
+// Defined for the labels of request counter.
+type requestLabels struct {
+	method string
+	route  string
+	status int
+}
+
+// Defined for the labels of duration histogram.
+type durationLabels struct {
+	method string
+	route  string
+}
+
+// Defined for a histogram of durations.
+type histogram struct {
+	counts []uint64 // counts of each bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// Create the metrics, the namespace is the prefix of metric names.
+// If the buckets is empty, DefaultBuckets is used.
+func NewMetrics(namespace string, buckets ...float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	bs := append([]float64(nil), buckets...)
+	sort.Float64s(bs)
+
+	return &Metrics{
+		namespace: namespace,
+		buckets:   bs,
+		requests:  make(map[requestLabels]uint64),
+		durations: make(map[durationLabels]*histogram),
+	}
+}
+
+// Get the middleware collecting the metrics. It should be global middleware,
+// so the not found requests are also collected.
+func (m *Metrics) Middleware() HandlerFunc {
+	return func(c *Context) {
+		start := time.Now()
+		atomic.AddInt64(&m.inFlight, 1)
+		defer func() {
+			atomic.AddInt64(&m.inFlight, -1)
+			m.observe(c, time.Since(start))
+		}()
+		c.Next()
+	}
+}
+
+// Record the handled request.
+func (m *Metrics) observe(c *Context, duration time.Duration) {
+	method := c.Request.Method
+	if !metricMethods[method] {
+		method = "OTHER"
+	}
+	route := unmatchedRoute
+	if c.Result != nil && c.Result.IsMatch {
+		route = c.Result.Url
+	}
+	status := c.Response.Status()
+	seconds := duration.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[requestLabels{method, route, status}]++
+	switch status {
+	case http.StatusNotFound:
+		m.notFound++
+	case http.StatusMethodNotAllowed:
+		m.methodNotAllowed++
+	}
+
+	key := durationLabels{method, route}
+	h, ok := m.durations[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(m.buckets))}
+		m.durations[key] = h
+	}
+	for i, bound := range m.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// Get the handler exposing the metrics in Prometheus text format.
+func (m *Metrics) Handler() HandlerFunc {
+	return func(c *Context) {
+		c.SetHeader("Content-Type", MIMEPrometheus)
+		c.Status(http.StatusOK)
+		if c.Request.Method == "HEAD" {
+			return
+		}
+		if _, err := m.WriteTo(c.Response); err != nil {
+			c.mux.Logger.Printf("write metrics error: %v", err)
+		}
+	}
+}
+
+// Write the metrics in Prometheus text format.
+// The series are sorted, so the output is stable. The series are copied
+// under the lock, so the slow writer doesn't block the requests.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	type requestSeries struct {
+		requestLabels
+		count uint64
+	}
+	type durationSeries struct {
+		durationLabels
+		histogram
+	}
+
+	m.mu.Lock()
+	requests := make([]requestSeries, 0, len(m.requests))
+	for labels, count := range m.requests {
+		requests = append(requests, requestSeries{labels, count})
+	}
+	durations := make([]durationSeries, 0, len(m.durations))
+	for labels, h := range m.durations {
+		copied := *h
+		copied.counts = append([]uint64(nil), h.counts...)
+		durations = append(durations, durationSeries{labels, copied})
+	}
+	notFound, methodNotAllowed := m.notFound, m.methodNotAllowed
+	m.mu.Unlock()
+
+	sort.Slice(requests, func(i, j int) bool {
+		a, b := requests[i], requests[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.status < b.status
+	})
+	sort.Slice(durations, func(i, j int) bool {
+		a, b := durations[i], durations[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		return a.method < b.method
+	})
+
+	cw := &countWriter{w: bufio.NewWriter(w)}
+	name := m.name
+
+	writeMetricHeader(cw, name("http_requests_total"), "counter", "The total number of HTTP requests.")
+	for _, r := range requests {
+		fmt.Fprintf(cw, "%s{method=%s,route=%s,status=\"%d\"} %d\n", name("http_requests_total"),
+			quoteLabel(r.method), quoteLabel(r.route), r.status, r.count)
+	}
+
+	hist := name("http_request_duration_seconds")
+	writeMetricHeader(cw, hist, "histogram", "The HTTP request latencies in seconds.")
+	for _, d := range durations {
+		labels := "method=" + quoteLabel(d.method) + ",route=" + quoteLabel(d.route)
+		var cumulative uint64
+		for i, bound := range m.buckets {
+			cumulative += d.counts[i]
+			fmt.Fprintf(cw, "%s_bucket{%s,le=\"%s\"} %d\n", hist, labels, formatFloat(bound), cumulative)
+		}
+		fmt.Fprintf(cw, "%s_bucket{%s,le=\"+Inf\"} %d\n", hist, labels, d.count)
+		fmt.Fprintf(cw, "%s_sum{%s} %s\n", hist, labels, formatFloat(d.sum))
+		fmt.Fprintf(cw, "%s_count{%s} %d\n", hist, labels, d.count)
+	}
+
+	writeMetricHeader(cw, name("http_not_found_total"), "counter", "The total number of 404 responses.")
+	fmt.Fprintf(cw, "%s %d\n", name("http_not_found_total"), notFound)
+	writeMetricHeader(cw, name("http_method_not_allowed_total"), "counter", "The total number of 405 responses.")
+	fmt.Fprintf(cw, "%s %d\n", name("http_method_not_allowed_total"), methodNotAllowed)
+
+	writeMetricHeader(cw, name("http_requests_in_flight"), "gauge", "The number of HTTP requests being served.")
+	fmt.Fprintf(cw, "%s %d\n", name("http_requests_in_flight"), atomic.LoadInt64(&m.inFlight))
+
+	if err := cw.w.Flush(); err != nil {
+		return cw.n, err
+	}
+	return cw.n, nil
+}
+
+// Get the metric name with the namespace.
+func (m *Metrics) name(name string) string {
+	if m.namespace == "" {
+		return name
+	}
+	return m.namespace + "_" + name
+}
+
+// Write the HELP and TYPE lines of the metric.
+func writeMetricHeader(w io.Writer, name string, typ string, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// Quote the label value, the backslash, double quote and line feed are escaped.
+func quoteLabel(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+	return `"` + value + `"`
+}
+
+// Format the float in the shortest form, such as "0.005" and "1".
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// Defined for a writer counting the written bytes.
+type countWriter struct {
+	w *bufio.Writer
+	n int64
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,60 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := NewMetrics("myapp", 0.5, 0.1)
+	mux := NewMux("myMux")
+	mux.Use(metrics.Middleware())
+	mux.Add([]string{"GET"}, "/users/(id)", urlHandler)
+	mux.Add([]string{"GET"}, "/metrics", metrics.Handler())
+	mux.Start()
+
+	serve(mux, "GET", "/users/1")
+	serve(mux, "GET", "/users/2")
+	serve(mux, "POST", "/users/3")
+	serve(mux, "GET", "/none/1")
+	serve(mux, "BREW", "/none/2")
+
+	w := serve(mux, "GET", "/metrics")
+	body := w.Body.String()
+	assertTrue(w.Header().Get("Content-Type") == MIMEPrometheus, "case1", t)
+	assertTrue(strings.Contains(body, "# TYPE myapp_http_requests_total counter\n"), "case1", t)
+
+	// labeled by route pattern, not the raw url
+	assertTrue(strings.Contains(body, `myapp_http_requests_total{method="GET",route="/users/(id)",status="200"} 2`), "case2", t)
+	assertTrue(strings.Contains(body, `myapp_http_requests_total{method="GET",route="unmatched",status="404"} 1`), "case2", t)
+	assertTrue(strings.Contains(body, `myapp_http_requests_total{method="OTHER",route="unmatched",status="404"} 1`), "case2", t)
+	assertFalse(strings.Contains(body, "/users/1"), "case2", t)
+
+	// buckets are sorted and cumulative
+	assertTrue(strings.Contains(body, `myapp_http_request_duration_seconds_bucket{method="GET",route="/users/(id)",le="0.1"} 2`), "case3", t)
+	assertTrue(strings.Contains(body, `myapp_http_request_duration_seconds_bucket{method="GET",route="/users/(id)",le="0.5"} 2`), "case3", t)
+	assertTrue(strings.Contains(body, `myapp_http_request_duration_seconds_bucket{method="GET",route="/users/(id)",le="+Inf"} 2`), "case3", t)
+	assertTrue(strings.Contains(body, `myapp_http_request_duration_seconds_count{method="GET",route="/users/(id)"} 2`), "case3", t)
+	assertTrue(strings.Index(body, `le="0.1"`) < strings.Index(body, `le="0.5"`), "case3", t)
+
+	assertTrue(strings.Contains(body, "myapp_http_not_found_total 2\n"), "case4", t)
+	assertTrue(strings.Contains(body, "myapp_http_method_not_allowed_total 1\n"), "case4", t)
+	assertTrue(strings.Contains(body, `myapp_http_requests_total{method="POST",route="unmatched",status="405"} 1`), "case4", t)
+	// the metrics request itself is in flight
+	assertTrue(strings.Contains(body, "myapp_http_requests_in_flight 1\n"), "case4", t)
+
+	var buf bytes.Buffer
+	n, err := metrics.WriteTo(&buf)
+	assertTrue(err == nil && n == int64(buf.Len()), "case5", t)
+	assertTrue(strings.Contains(buf.String(), "myapp_http_requests_in_flight 0\n"), "case5", t)
+}
+
+func TestQuoteLabel(t *testing.T) {
+	assertTrue(quoteLabel(`/a"b\c`+"\n") == `"/a\"b\\c\n"`, "case1", t)
+	assertTrue(NewMetrics("").name("x") == "x", "case2", t)
+}
@@ -0,0 +1,78 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"net/http"
+	"testing"
+)
+
+// Create a middleware writing the name before and after the next handlers.
+func traceMiddleware(name string) HandlerFunc {
+	return func(c *Context) {
+		c.Response.Write([]byte(name + "<"))
+		c.Next()
+		c.Response.Write([]byte(">" + name))
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	mux := NewMux("myMux")
+	api := mux.Group("/api", traceMiddleware("g"))
+	api.Add([]string{"GET"}, "/users", urlHandler, traceMiddleware("r1"), traceMiddleware("r2"))
+	api.Group("/admin", traceMiddleware("s")).Add([]string{"GET"}, "/", urlHandler)
+	mux.Add([]string{"GET"}, "/home", urlHandler)
+	// use after routes are added
+	mux.Use(traceMiddleware("m"))
+	assertTrue(mux.Start() == nil, "case0", t)
+
+	w1 := serve(mux, "GET", "/api/users")
+	assertTrue(w1.Body.String() == "m<g<r1<r2</api/users>r2>r1>g>m", "case1", t)
+	w2 := serve(mux, "GET", "/api/admin")
+	assertTrue(w2.Body.String() == "m<g<s</api/admin/>s>g>m", "case2", t)
+	w3 := serve(mux, "GET", "/home")
+	assertTrue(w3.Body.String() == "m</home>m", "case3", t)
+
+	// global middleware runs for not found requests,
+	// the error body is dropped because the response is already written
+	w4 := serve(mux, "GET", "/none")
+	assertTrue(w4.Body.String() == "m<>m", "case4", t)
+}
+
+func TestMiddlewareStop(t *testing.T) {
+	var matched string
+	auth := func(c *Context) {
+		matched = c.Result.Url + " " + c.Param("id")
+		if c.Header("Authorization") == "" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+		}
+	}
+
+	mux := NewMux("myMux")
+	mux.Add([]string{"GET"}, "/users/(id)", urlHandler, auth)
+	mux.Start()
+
+	w1 := serve(mux, "GET", "/users/1")
+	assertTrue(w1.Code == http.StatusUnauthorized && w1.Body.Len() == 0, "case1", t)
+	assertTrue(matched == "/users/(id) 1", "case1", t)
+
+	w2 := serve(mux, "GET", "/users/2", "Authorization", "token")
+	assertTrue(w2.Body.String() == "/users/(id)", "case2", t)
+	assertTrue(matched == "/users/(id) 2", "case2", t)
+}
+
+func TestMiddlewareWithoutNext(t *testing.T) {
+	mux := NewMux("myMux")
+	mux.Use(func(c *Context) {
+		c.Response.Write([]byte("a,"))
+	}, func(c *Context) {
+		c.Response.Write([]byte("b,"))
+	})
+	mux.Add([]string{"GET"}, "/home", urlHandler)
+	mux.Start()
+
+	w1 := serve(mux, "GET", "/home")
+	assertTrue(w1.Body.String() == "a,b,/home", "case1", t)
+}
@@ -0,0 +1,96 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/uestcer/light/view"
+	"reflect"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+)
+
+// Run modes of the app.
+const (
+	ModeDevelopment = "development"
+	ModeTest        = "test"
+	ModeProduction  = "production"
+)
+
+// The environment variable of the run mode.
+const EnvMode = "LIGHT_MODE"
+
+// Get the full name of the run mode, the short names "dev" and "prod" are
+// also accepted. The empty mode is production, so the app deployed without
+// LIGHT_MODE doesn't show the debug information.
+// Return empty string, when the mode is unknown.
+func parseMode(mode string) string {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "dev", ModeDevelopment:
+		return ModeDevelopment
+	case ModeTest:
+		return ModeTest
+	case "", "prod", ModeProduction:
+		return ModeProduction
+	}
+	return ""
+}
+
+// Get the config profile of the run mode, such as "prod" for production.
+// Return empty string, when the mode is unknown.
+func modeProfile(mode string) string {
+	switch parseMode(mode) {
+	case ModeDevelopment:
+		return ProfileDev
+	case ModeTest:
+		return ProfileTest
+	case ModeProduction:
+		return ProfileProd
+	}
+	return ""
+}
+
+// Apply the run mode when the app starts:
+//
+//	              development  test  production
+//	Debug page    on           off   off
+//	View reload   on           off   off
+//	Route table   printed      -     -
+//	Strict routes on           on    off
+func (a *App) applyMode() {
+	dev := a.Mode == ModeDevelopment
+	a.Debug = dev
+	a.Strict = a.Mode != ModeProduction
+	if engine, ok := a.View.(*view.Engine); ok {
+		engine.Reload = dev
+	}
+}
+
+// Print the route table by the logger.
+func (a *App) printRoutes() {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "METHODS\tURL\tNAME\tACCESS\tHANDLER\n")
+	for _, rt := range a.routes {
+		methods := strings.Join(rt.methods, ",")
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", methods, rt.url, rt.name, rt.Access(), funcName(rt.handler))
+	}
+	w.Flush()
+	a.Logger.Printf("%s mode, routes of %s:\n%s", a.Mode, a.router.Name(), buf.String())
+}
+
+// Get the name of the handler func.
+func funcName(handler HandlerFunc) string {
+	if handler == nil {
+		return "<nil>"
+	}
+	f := runtime.FuncForPC(reflect.ValueOf(handler).Pointer())
+	if f == nil {
+		return "?"
+	}
+	return f.Name()
+}
@@ -0,0 +1,97 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"bytes"
+	"github.com/uestcer/light/view"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func modeApp(mode string) (*App, *bytes.Buffer) {
+	var out bytes.Buffer
+	app := NewApp("myApp")
+	app.Mode = mode
+	app.Logger = log.New(&out, "", 0)
+	app.View = view.NewFS(fstest.MapFS{}, ".html")
+	app.Add([]string{"GET"}, "/users/(id)", urlHandler).Name("user").RequireRoles("admin")
+	return app, &out
+}
+
+func TestAppMode(t *testing.T) {
+	app, out := modeApp("dev")
+	assertTrue(app.Start() == nil && app.Mode == ModeDevelopment, "case1", t)
+	assertTrue(app.Debug && app.Strict && app.View.(*view.Engine).Reload, "case1", t)
+	assertTrue(strings.Contains(out.String(), "development mode, routes of myApp:"), "case1", t)
+	assertTrue(strings.Contains(out.String(), "GET      /users/(id)  user  roles=admin  github.com/uestcer/light.urlHandler"), "case1", t)
+
+	app, out = modeApp(ModeTest)
+	assertTrue(app.Start() == nil, "case2", t)
+	assertTrue(!app.Debug && app.Strict && !app.View.(*view.Engine).Reload, "case2", t)
+	assertTrue(out.Len() == 0, "case2", t)
+
+	app, out = modeApp("PROD")
+	app.Debug = true
+	assertTrue(app.Start() == nil && app.Mode == ModeProduction, "case3", t)
+	assertTrue(!app.Debug && !app.Strict && out.Len() == 0, "case3", t)
+
+	app, _ = modeApp("staging")
+	assertTrue(app.Start() != nil, "case4", t)
+
+	os.Setenv(EnvMode, "production")
+	defer os.Unsetenv(EnvMode)
+	assertTrue(NewApp("myApp").Mode == "production", "case5", t)
+	assertTrue(NewApp("myApp").Config.Profile() == ProfileProd, "case5", t)
+	os.Setenv(EnvMode, "dev")
+	assertTrue(NewApp("myApp").Config.Profile() == ProfileDev, "case5", t)
+
+	// production by default
+	os.Unsetenv(EnvMode)
+	assertTrue(NewApp("myApp").Config.Profile() == ProfileProd, "case6", t)
+	app, out = modeApp(NewApp("myApp").Mode)
+	app.Debug = true
+	assertTrue(app.Start() == nil && app.Mode == ModeProduction, "case6", t)
+	assertTrue(!app.Debug && out.Len() == 0, "case6", t)
+
+	// the profile variable wins
+	os.Setenv(EnvMode, "production")
+	os.Setenv(EnvProfile, "staging")
+	defer os.Unsetenv(EnvProfile)
+	assertTrue(NewApp("myApp").Config.Profile() == "staging", "case7", t)
+}
+
+func TestMuxStrict(t *testing.T) {
+	strict := func(add func(mux *Mux)) bool {
+		mux := NewMux("myMux")
+		mux.Strict = true
+		add(mux)
+		return mux.Start() == nil
+	}
+
+	assertTrue(strict(func(mux *Mux) {
+		mux.Add([]string{"GET"}, "/users/(id)", urlHandler)
+		mux.Add([]string{"POST"}, "/users/(name)", urlHandler)
+		mux.Add([]string{"GET"}, "/users/(id:int)", urlHandler)
+	}), "case1", t)
+	assertFalse(strict(func(mux *Mux) {
+		mux.Add([]string{"GET"}, "/users/(id)", urlHandler)
+		mux.Add([]string{"GET", "POST"}, "/users/(name)/", urlHandler)
+	}), "case2", t)
+	assertFalse(strict(func(mux *Mux) {
+		mux.Add([]string{"get"}, "/users", urlHandler)
+	}), "case3", t)
+	assertFalse(strict(func(mux *Mux) {
+		mux.Add([]string{"GET"}, "users", urlHandler)
+	}), "case4", t)
+	assertFalse(strict(func(mux *Mux) {
+		mux.Add([]string{"GET"}, "/users", nil)
+	}), "case5", t)
+
+	assertTrue(routeShape("/a/ p(id:\\d+)s /(name)/") == "/a/p(:\\d+)s/()", "case6", t)
+}
@@ -0,0 +1,617 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"fmt"
+	"github.com/arging/utils/errors"
+	"github.com/uestcer/light/router"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ http.Handler = &Mux{}
+
+// HandlerFunc handles the request which matched a route.
+//
+// Middleware is also a HandlerFunc, which runs around the handler of a route.
+// It can see the routing result by Context.Result, run the next handlers by
+// Context.Next, and stop the chain early by Context.Abort:
+//
+//	func auth(c *light.Context) {
+//		if c.Header("Authorization") == "" {
+//			c.AbortWithStatus(http.StatusUnauthorized)
+//			return
+//		}
+//		c.Next()
+//		// after the handler
+//	}
+//
+// If middleware doesn't call Next, the next handlers run after it returns.
+type HandlerFunc func(c *Context)
+
+// Mux binds handlers to the routes of a Router and dispatches the
+// http requests to them. Before serving, you should start the Mux.
+type Mux struct {
+	*RouteGroup
+
+	// NotFound handles the requests which match no route.
+	// If it is nil, a 404 HTTPError is replied by the ErrorHandler.
+	NotFound HandlerFunc
+
+	// MethodNotAllowed handles the requests which match the routes of other
+	// methods, the Allow header is set before. If it is nil, a 405 HTTPError
+	// is replied by the ErrorHandler.
+	MethodNotAllowed HandlerFunc
+
+	// ErrorHandler replies the errors of Context.Error.
+	// Default is DefaultErrorHandler.
+	ErrorHandler ErrorHandler
+
+	// Reply all errors as problem details of RFC 7807 by DefaultErrorHandler.
+	// Otherwise, problem details are replied when the client accepts them.
+	ProblemDetails bool
+
+	// Show the debug information, such as the debug page of Recovery.
+	Debug bool
+
+	// Logger logs the internal errors.
+	Logger *log.Logger
+
+	// View renders the HTML templates for Context.HTML.
+	View View
+
+	// Strip the format suffix, such as ".json", from the url for routing.
+	// So the route "/users/(id)" matches "/users/1.json" with the id "1",
+	// and the suffix is used by Context.Format.
+	FormatSuffix bool
+
+	// Validate the routes strictly at Start. The route must have a handler,
+	// the url must start with "/", the methods must be upper case, and no
+	// route may be shadowed by another one with the same method and shape,
+	// such as "/users/(id)" and "/users/(name)".
+	Strict bool
+
+	router        router.Router
+	routes        []*Route
+	fallbacks     []*fallback
+	handlers      map[string]map[string]*Route // method -> url -> route
+	names         map[string]*Route            // name -> route
+	global        []HandlerFunc                // global middleware
+	notFoundChain []HandlerFunc                // NotFound or MethodNotAllowed with global middleware
+	pool          sync.Pool                    // pool of Context
+}
+
+// Route is a url bound with handler, it is returned when added to the Mux.
+type Route struct {
+	name       string
+	methods    []string
+	url        string
+	handler    HandlerFunc
+	group      *RouteGroup   // the group which the route is added to
+	middleware []HandlerFunc // per-route middleware
+	chain      []HandlerFunc // all middleware and the handler
+	meta       router.Metadata
+	resolved   router.Metadata // the metadata with the groups', added to the router
+}
+
+// Defined for a fallback handler of a group.
+type fallback struct {
+	pieces  []string // pieces of the group prefix
+	handler HandlerFunc
+	group   *RouteGroup
+	chain   []HandlerFunc
+	meta    router.Metadata // the metadata of the groups, resolved at Start
+}
+
+// Create a mux with a new Router by name.
+func NewMux(name string) *Mux {
+	m := &Mux{
+		ErrorHandler: DefaultErrorHandler,
+		Logger:       log.New(os.Stderr, "[light] ", log.LstdFlags),
+		router:       router.New(name),
+	}
+	m.RouteGroup = &RouteGroup{mux: m}
+	m.pool.New = func() interface{} {
+		return &Context{mux: m}
+	}
+	return m
+}
+
+// Get the Router which the routes are added to.
+func (m *Mux) Router() router.Router {
+	return m.router
+}
+
+// Start the mux and its Router.
+func (m *Mux) Start() errors.Error {
+	if m.Strict {
+		if err := m.validate(); err != nil {
+			return err
+		}
+	}
+
+	handlers := make(map[string]map[string]*Route)
+	names := make(map[string]*Route)
+	for _, rt := range m.routes {
+		if rt.name != "" {
+			if _, ok := names[rt.name]; ok {
+				return errors.Newf("mux duplicate route name: %s.", rt.name)
+			}
+			names[rt.name] = rt
+		}
+
+		// The requests are routed by their methods, so the route without
+		// methods could never match.
+		if len(rt.methods) == 0 {
+			return errors.Newf("mux route error, no methods: %s.", rt.url)
+		}
+
+		for _, method := range rt.methods {
+			urlMap, ok := handlers[method]
+			if !ok {
+				urlMap = make(map[string]*Route)
+				handlers[method] = urlMap
+			}
+			if _, ok := urlMap[rt.url]; ok {
+				return errors.Newf("mux duplicate route: %s %s.", method, rt.url)
+			}
+			urlMap[rt.url] = rt
+		}
+		rt.chain = chain(rt.handler, rt.group.middlewares(), rt.middleware)
+		rt.resolve()
+	}
+
+	for _, fb := range m.fallbacks {
+		fb.chain = chain(fb.handler, fb.group.middlewares())
+		fb.meta = make(router.Metadata)
+		fb.group.resolve(fb.meta)
+	}
+	m.global = m.RouteGroup.middlewares()
+	m.notFoundChain = chain(m.handleUnmatched, m.global)
+
+	if err := m.router.Start(); err != nil {
+		return errors.Wrapf(err, "mux start error: %s.", m.router.Name())
+	}
+	m.handlers = handlers
+	m.names = names
+	return nil
+}
+
+// Validate the routes strictly, see Strict.
+func (m *Mux) validate() errors.Error {
+	shapes := make(map[string]*Route) // method shape -> route
+	for _, rt := range m.routes {
+		if rt.handler == nil {
+			return errors.Newf("mux strict error, no handler: %s.", rt.url)
+		}
+		if !strings.HasPrefix(rt.url, pathSep) {
+			return errors.Newf("mux strict error, url must start with %q: %s.", pathSep, rt.url)
+		}
+
+		shape := routeShape(rt.url)
+		for _, method := range rt.methods {
+			if method == "" || method != strings.ToUpper(method) {
+				return errors.Newf("mux strict error, bad method %q: %s.", method, rt.url)
+			}
+			if other, ok := shapes[method+" "+shape]; ok && other.url != rt.url {
+				return errors.Newf("mux strict error, %s %s is shadowed by %s.", method, rt.url, other.url)
+			}
+			shapes[method+" "+shape] = rt
+		}
+	}
+	return nil
+}
+
+// Get the shape of the url, the param names are dropped and the empty pieces
+// are ignored, such as "/users/(id)/" to "/users/()".
+func routeShape(url string) string {
+	pieces := splitPath(url)
+	for i, piece := range pieces {
+		piece = strings.TrimSpace(piece)
+		l, r := strings.Index(piece, "("), strings.LastIndex(piece, ")")
+		if l == -1 || r < l {
+			pieces[i] = piece
+			continue
+		}
+		content := piece[l+1 : r]
+		if j := strings.Index(content, ":"); j >= 0 {
+			content = content[j:]
+		} else {
+			content = ""
+		}
+		pieces[i] = piece[:l] + "(" + strings.TrimSpace(content) + ")" + piece[r+1:]
+	}
+	return pathSep + strings.Join(pieces, pathSep)
+}
+
+// Get all the routes in adding order.
+func (m *Mux) Routes() []*Route {
+	return append([]*Route(nil), m.routes...)
+}
+
+// Build the url of the named route by the params.
+// The params are name and value pairs, such as:
+//
+//	mux.URL("article", "id", 1, "page", 2)
+//
+// The value is formatted by fmt.Sprint, a slice value is a multi-valued param.
+// It returns error as the last result, so it can be a template func.
+func (m *Mux) URL(name string, params ...interface{}) (string, error) {
+	rt, ok := m.names[name]
+	if !ok {
+		return "", errors.Newf("mux url error, no route named: %s.", name)
+	}
+	if len(params)%2 != 0 {
+		return "", errors.Newf("mux url error, params must be pairs: %s.", name)
+	}
+
+	values := make(map[string][]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		key := fmt.Sprint(params[i])
+		if rv := reflect.ValueOf(params[i+1]); rv.Kind() == reflect.Slice {
+			for j := 0; j < rv.Len(); j++ {
+				values[key] = append(values[key], fmt.Sprint(rv.Index(j).Interface()))
+			}
+		} else {
+			values[key] = append(values[key], fmt.Sprint(params[i+1]))
+		}
+	}
+
+	url, err := router.BuildUrl(rt.url, values)
+	if err != nil {
+		return "", errors.Wrapf(err, "mux url error: %s.", name)
+	}
+	return url, nil
+}
+
+func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	url, format := r.URL.Path, ""
+	if m.FormatSuffix {
+		url, format = splitFormat(url)
+	}
+
+	c := m.pool.Get().(*Context)
+	start := time.Now()
+	result, p := m.route(r.Method, url)
+	c.reset(w, r, result)
+	c.format = format
+	c.start, c.routed = start, time.Now()
+
+	if p != nil {
+		// Panic again in the global middleware, so Recovery can handle it.
+		c.run(chain(func(c *Context) { panic(p) }, m.global))
+	} else if rt, fb := m.lookup(r.Method, result), m.fallback(r); rt != nil && !fb.deeper(result) {
+		c.route, c.group = rt, rt.group
+		c.run(rt.chain)
+	} else if fb != nil {
+		if result.IsMatch {
+			c.Result = &router.Result{}
+		}
+		c.group, c.fellBack, c.meta = fb.group, true, fb.meta
+		c.run(fb.chain)
+	} else {
+		c.group = m.RouteGroup
+		c.run(m.notFoundChain)
+	}
+	m.pool.Put(c)
+}
+
+// Route the url by the Router, and recover the panic of routing.
+func (m *Mux) route(method string, url string) (result *router.Result, p interface{}) {
+	defer func() {
+		if p = recover(); p != nil {
+			result = &router.Result{}
+		}
+	}()
+	return m.router.Route(method, url), nil
+}
+
+// Get the route bound to the matched result.
+// Return nil, when the result doesn't match any route.
+func (m *Mux) lookup(method string, result *router.Result) *Route {
+	if !result.IsMatch {
+		return nil
+	}
+	return m.handlers[method][result.Url]
+}
+
+// Handle the request which is not found by a matched route, such as the
+// missing file of static route. The request is already in the middleware chain
+// of the route, so only the middleware of the fallback groups which hasn't run
+// is run before the fallback, and the access rules of the fallback groups are
+// checked again if Authorize has run. The NotFound handler is called directly.
+func (m *Mux) notFound(c *Context) {
+	if fb := m.fallback(c.Request); fb != nil && !c.fellBack {
+		c.fellBack, c.meta = true, fb.meta
+		handlers := fb.group.middlewaresAfter(c.group)
+		if _, ok := c.Get(authorizedKey); ok {
+			handlers = append([]HandlerFunc{authorize}, handlers...)
+		}
+		c.run(chain(fb.handler, handlers))
+		return
+	}
+	m.handleNotFound(c)
+}
+
+// Handle the request which matches no route of its method. It is replied 405
+// with the Allow header, when the url matches the routes of other methods.
+func (m *Mux) handleUnmatched(c *Context) {
+	methods := m.allowedMethods(c.Request.URL.Path)
+	if len(methods) == 0 {
+		m.handleNotFound(c)
+		return
+	}
+
+	c.SetHeader("Allow", strings.Join(methods, ", "))
+	if m.MethodNotAllowed != nil {
+		m.MethodNotAllowed(c)
+		return
+	}
+	c.Error(NewHTTPError(http.StatusMethodNotAllowed, ""))
+}
+
+func (m *Mux) handleNotFound(c *Context) {
+	if m.NotFound != nil {
+		m.NotFound(c)
+		return
+	}
+	c.Error(NewHTTPError(http.StatusNotFound, ""))
+}
+
+// Get the fallback for the request.
+// Only GET and HEAD requests for the url without file extension fall back,
+// and the fallback of the longest matched group prefix is chosen.
+func (m *Mux) fallback(r *http.Request) *fallback {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		return nil
+	}
+
+	pieces := splitPath(r.URL.Path)
+	if len(pieces) > 0 && path.Ext(pieces[len(pieces)-1]) != "" {
+		return nil
+	}
+
+	var target *fallback
+	for _, fb := range m.fallbacks {
+		if hasPrefix(pieces, fb.pieces) &&
+			(target == nil || len(fb.pieces) > len(target.pieces)) {
+			target = fb
+		}
+	}
+	return target
+}
+
+// Is the group prefix of the fallback deeper than the url of the matched
+// result, which matches the request by its prefix, such as "/" matches
+// "/app/settings". The deeper fallback is more specific than the route.
+func (fb *fallback) deeper(result *router.Result) bool {
+	return fb != nil && len(fb.pieces) > len(splitPath(result.Url))
+}
+
+// RouteGroup is a set of routes sharing the same url prefix and middleware.
+type RouteGroup struct {
+	mux        *Mux
+	parent     *RouteGroup
+	prefix     string
+	middleware []HandlerFunc
+	meta       router.Metadata
+}
+
+// Create a sub group, the prefix is appended to current group prefix.
+// The sub group runs the middleware of current group before its own.
+func (g *RouteGroup) Group(prefix string, middleware ...HandlerFunc) *RouteGroup {
+	return &RouteGroup{mux: g.mux, parent: g, prefix: joinUrl(g.prefix, prefix), middleware: middleware}
+}
+
+// Set the metadata of the group, such as "csrf.exempt" for webhooks.
+// The routes of the group and its sub groups inherit the metadata.
+func (g *RouteGroup) Meta(key string, value interface{}) *RouteGroup {
+	if g.meta == nil {
+		g.meta = make(router.Metadata)
+	}
+	g.meta[key] = value
+	return g
+}
+
+// Get the metadata of the group by key, it is inherited from the parents.
+// Return false, when the key is not set.
+func (g *RouteGroup) Metadata(key string) (interface{}, bool) {
+	for ; g != nil; g = g.parent {
+		if value, ok := g.meta[key]; ok {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// Use the middleware for all routes of the group, including the routes
+// added before and the routes of sub groups.
+// Middleware of the Mux is global, it also runs for not found requests.
+func (g *RouteGroup) Use(middleware ...HandlerFunc) {
+	g.middleware = append(g.middleware, middleware...)
+}
+
+// Get the middleware of the group and its parents, in running order.
+func (g *RouteGroup) middlewares() []HandlerFunc {
+	if g.parent == nil {
+		return g.middleware
+	}
+	parent := g.parent.middlewares()
+	mws := make([]HandlerFunc, 0, len(parent)+len(g.middleware))
+	return append(append(mws, parent...), g.middleware...)
+}
+
+// Get the middleware of the group and its parents which is not the middleware
+// of the ran group and its parents, in running order.
+func (g *RouteGroup) middlewaresAfter(ran *RouteGroup) []HandlerFunc {
+	ancestors := make(map[*RouteGroup]bool)
+	for ; ran != nil; ran = ran.parent {
+		ancestors[ran] = true
+	}
+	var groups []*RouteGroup
+	for ; g != nil && !ancestors[g]; g = g.parent {
+		groups = append(groups, g)
+	}
+
+	var mws []HandlerFunc
+	for i := len(groups) - 1; i >= 0; i-- {
+		mws = append(mws, groups[i].middleware...)
+	}
+	return mws
+}
+
+// Get the url prefix of the group.
+func (g *RouteGroup) Prefix() string {
+	return g.prefix
+}
+
+// Add route url by specified methods, and bind the handler to it.
+// The methods are required, the Mux fails to start without them.
+// The url is relative to the group prefix. The middleware only runs for
+// this route, after the middleware of the group.
+func (g *RouteGroup) Add(methods []string, url string, handler HandlerFunc, middleware ...HandlerFunc) *Route {
+	url = joinUrl(g.prefix, url)
+	rt := &Route{methods: methods, url: url, handler: handler, group: g, middleware: middleware,
+		resolved: make(router.Metadata)}
+	g.mux.routes = append(g.mux.routes, rt)
+	g.mux.router.Add(methods, url, rt.resolved)
+	return rt
+}
+
+// Bind the fallback handler to the group.
+// When no route matches a GET or HEAD request under the group prefix,
+// and the url has no file extension, the request falls back to the handler.
+// A route outside the group, which matches the request by its prefix such as
+// "/", doesn't win over the fallback. It is useful for single-page apps, such as:
+//
+//	app := mux.Group("/app")
+//	assets := app.StaticFS("/", dist)
+//	app.Fallback(assets.FileHandler("index.html"))
+func (g *RouteGroup) Fallback(handler HandlerFunc) {
+	fb := &fallback{pieces: splitPath(g.prefix), handler: handler, group: g}
+	g.mux.fallbacks = append(g.mux.fallbacks, fb)
+}
+
+// Name the route, so its url can be built by Mux.URL.
+func (rt *Route) Name(name string) *Route {
+	rt.name = name
+	return rt
+}
+
+// Set the metadata of the route, such as "csrf.exempt" for webhooks.
+// It overrides the metadata of the groups. The metadata should be set before
+// the Mux starts, then it is also returned in the routing result as
+// Result.Meta, including the inherited ones.
+func (rt *Route) Meta(key string, value interface{}) *Route {
+	if rt.meta == nil {
+		rt.meta = make(router.Metadata)
+	}
+	rt.meta[key] = value
+	return rt
+}
+
+// Get the metadata of the route by key, it is inherited from the groups.
+// It reads the current metadata for introspection, the requests read the
+// metadata resolved at Start by Context.Metadata.
+// Return false, when the key is not set.
+func (rt *Route) Metadata(key string) (interface{}, bool) {
+	if value, ok := rt.meta[key]; ok {
+		return value, true
+	}
+	return rt.group.Metadata(key)
+}
+
+// Resolve the metadata of the groups and the route for the router, the
+// outer group is overridden by the inner one and the route.
+func (rt *Route) resolve() {
+	for k := range rt.resolved {
+		delete(rt.resolved, k)
+	}
+	rt.group.resolve(rt.resolved)
+	for k, v := range rt.meta {
+		rt.resolved[k] = v
+	}
+}
+
+// Resolve the metadata of the group and its parents into the map, the outer
+// group is overridden by the inner one.
+func (g *RouteGroup) resolve(meta router.Metadata) {
+	if g == nil {
+		return
+	}
+	g.parent.resolve(meta)
+	for k, v := range g.meta {
+		meta[k] = v
+	}
+}
+
+// Get the url of the route, including the group prefix.
+func (rt *Route) Url() string {
+	return rt.url
+}
+
+// Get the methods of the route.
+func (rt *Route) Methods() []string {
+	return rt.methods
+}
+
+// Join the middleware lists and the handler into a chain.
+// The first middleware of the first list is the outermost one.
+func chain(handler HandlerFunc, lists ...[]HandlerFunc) []HandlerFunc {
+	size := 1
+	for _, mws := range lists {
+		size += len(mws)
+	}
+
+	handlers := make([]HandlerFunc, 0, size)
+	for _, mws := range lists {
+		handlers = append(handlers, mws...)
+	}
+	return append(handlers, handler)
+}
+
+// Join the prefix and url with a single path separator.
+func joinUrl(prefix string, url string) string {
+	if prefix == "" {
+		return url
+	}
+	if url == "" {
+		return prefix
+	}
+	return strings.TrimRight(prefix, pathSep) + pathSep + strings.TrimLeft(url, pathSep)
+}
+
+// Split the url path into pieces, the empty pieces are dropped.
+// Unlike the router, pieces are not trimmed, so file names keep their spaces.
+func splitPath(url string) []string {
+	strs := strings.Split(url, pathSep)
+	pieces := make([]string, 0, len(strs))
+	for _, s := range strs {
+		if s != "" {
+			pieces = append(pieces, s)
+		}
+	}
+	return pieces
+}
+
+// Is the pieces start with the prefix pieces.
+func hasPrefix(pieces []string, prefix []string) bool {
+	if len(pieces) < len(prefix) {
+		return false
+	}
+	for i, piece := range prefix {
+		if pieces[i] != piece {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,243 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"github.com/arging/utils/errors"
+	"github.com/uestcer/light/router"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func assertTrue(rs bool, msg string, t *testing.T) {
+	if !rs {
+		// Track the test error source.
+		t.Error(errors.New(msg))
+	}
+}
+func assertFalse(rs bool, msg string, t *testing.T) {
+	assertTrue(!rs, msg, t)
+}
+
+// Serve a request by the handler and return the recorded response.
+func serve(h http.Handler, method string, url string, header ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, url, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+// Create a handler writing the matched url.
+func urlHandler(c *Context) {
+	c.Response.Write([]byte(c.Result.Url))
+}
+
+func TestJoinUrl(t *testing.T) {
+	assertTrue(joinUrl("", "/home") == "/home", "case1", t)
+	assertTrue(joinUrl("/api", "") == "/api", "case2", t)
+	assertTrue(joinUrl("/api/", "/home") == "/api/home", "case3", t)
+	assertTrue(joinUrl("/api", "home/") == "/api/home/", "case4", t)
+}
+
+func TestMuxServe(t *testing.T) {
+	mux := NewMux("myMux")
+	mux.Add([]string{"GET"}, "/home", urlHandler)
+	api := mux.Group("/api")
+	api.Add([]string{"GET", "POST"}, "/users/(id)", urlHandler)
+	assertTrue(mux.Start() == nil, "case1", t)
+
+	w2 := serve(mux, "GET", "/home")
+	assertTrue(w2.Code == 200 && w2.Body.String() == "/home", "case2", t)
+
+	w3 := serve(mux, "POST", "/api/users/1")
+	assertTrue(w3.Body.String() == "/api/users/(id)", "case3", t)
+
+	w4 := serve(mux, "POST", "/home")
+	assertTrue(w4.Code == http.StatusMethodNotAllowed && w4.Header().Get("Allow") == "GET", "case4", t)
+	w4 = serve(mux, "DELETE", "/api/users/1")
+	assertTrue(w4.Code == http.StatusMethodNotAllowed && w4.Header().Get("Allow") == "GET, POST", "case4", t)
+	w4 = serve(mux, "DELETE", "/none")
+	assertTrue(w4.Code == http.StatusNotFound && w4.Header().Get("Allow") == "", "case4", t)
+
+	mux.NotFound = func(c *Context) {
+		c.Status(http.StatusTeapot)
+	}
+	w5 := serve(mux, "GET", "/none")
+	assertTrue(w5.Code == http.StatusTeapot, "case5", t)
+
+	mux.MethodNotAllowed = func(c *Context) {
+		c.Status(http.StatusConflict)
+	}
+	w6 := serve(mux, "POST", "/home")
+	assertTrue(w6.Code == http.StatusConflict && w6.Header().Get("Allow") == "GET", "case6", t)
+}
+
+func TestMuxDuplicate(t *testing.T) {
+	mux := NewMux("myMux")
+	mux.Add([]string{"GET"}, "/home", urlHandler)
+	mux.Add([]string{"GET", "POST"}, "/home", urlHandler)
+	assertTrue(mux.Start() != nil, "case1", t)
+
+	// the route without methods can't match
+	mux = NewMux("myMux")
+	mux.Add(nil, "/any", urlHandler)
+	assertTrue(mux.Start() != nil, "case2", t)
+	mux = NewMux("myMux")
+	mux.Add([]string{}, "/any", urlHandler)
+	assertTrue(mux.Start() != nil, "case2", t)
+}
+
+func TestMuxFallback(t *testing.T) {
+	mux := NewMux("myMux")
+	mux.Add([]string{"GET"}, "/api/users", urlHandler)
+	app := mux.Group("/app")
+	assets := app.StaticFS("/", testFS())
+	app.Fallback(assets.FileHandler("index.html"))
+	admin := app.Group("/admin")
+	admin.Fallback(func(c *Context) {
+		c.Response.Write([]byte("admin"))
+	})
+	assertTrue(mux.Start() == nil, "case0", t)
+
+	// real assets and routes win
+	w1 := serve(mux, "GET", "/app/css/site.css")
+	assertTrue(w1.Body.String() == "body{}", "case1", t)
+	w2 := serve(mux, "GET", "/api/users")
+	assertTrue(w2.Body.String() == "/api/users", "case2", t)
+
+	// fall back to index
+	w3 := serve(mux, "GET", "/app/users/1/edit")
+	assertTrue(w3.Code == 200 && w3.Body.String() == "<p>index</p>", "case3", t)
+	w4 := serve(mux, "GET", "/app/admin/users")
+	assertTrue(w4.Body.String() == "admin", "case4", t)
+
+	// no fall back
+	w5 := serve(mux, "GET", "/app/css/none.css")
+	assertTrue(w5.Code == http.StatusNotFound, "case5", t)
+	w6 := serve(mux, "GET", "/api/none")
+	assertTrue(w6.Code == http.StatusNotFound, "case6", t)
+	w7 := serve(mux, "POST", "/app/users")
+	assertTrue(w7.Code == http.StatusMethodNotAllowed, "case7", t)
+	w8 := serve(mux, "GET", "/application")
+	assertTrue(w8.Code == http.StatusNotFound, "case8", t)
+
+	// the deeper fallback wins over the route matching by prefix
+	mux = NewMux("myMux")
+	mux.Add([]string{"GET"}, "/", urlHandler)
+	mux.Add([]string{"GET"}, "/api/users", urlHandler)
+	mux.Group("/app").Fallback(func(c *Context) { c.Response.Write([]byte("app")) })
+	mux.Group("/api/users/export").Fallback(func(c *Context) { c.Response.Write([]byte("export")) })
+	assertTrue(mux.Start() == nil, "case9", t)
+	assertTrue(serve(mux, "GET", "/app/settings").Body.String() == "app", "case9", t)
+	assertTrue(serve(mux, "GET", "/app").Body.String() == "app", "case9", t)
+	assertTrue(serve(mux, "GET", "/api/users/export/csv").Body.String() == "export", "case10", t)
+	assertTrue(serve(mux, "GET", "/api/users").Body.String() == "/api/users", "case11", t)
+	assertTrue(serve(mux, "GET", "/about").Body.String() == "/", "case11", t)
+}
+
+func TestMuxFallbackMiddleware(t *testing.T) {
+	var ran []string
+	mw := func(name string) HandlerFunc {
+		return func(c *Context) {
+			ran = append(ran, name)
+		}
+	}
+	mux := NewMux("myMux")
+	mux.Use(mw("global"))
+	mux.Add([]string{"GET"}, "/", func(c *Context) { c.NotFound() })
+	app := mux.Group("/app", mw("app"))
+	app.Add([]string{"GET"}, "/users/(id)", func(c *Context) { c.NotFound() })
+	app.Group("/admin", func(c *Context) {
+		c.AbortWithStatus(http.StatusUnauthorized)
+	}).Fallback(func(c *Context) {
+		c.NotFound()
+		c.Response.Write([]byte("admin"))
+	})
+	app.Fallback(func(c *Context) { c.Response.Write([]byte("app")) })
+	assertTrue(mux.Start() == nil, "case0", t)
+
+	// the middleware of the fallback groups runs once
+	w := serve(mux, "GET", "/app/page")
+	assertTrue(w.Code == http.StatusOK && w.Body.String() == "app", "case1", t)
+	assertTrue(strings.Join(ran, ",") == "global,app", "case1", t)
+	ran = nil
+	w = serve(mux, "GET", "/app/users/1")
+	assertTrue(w.Body.String() == "app" && strings.Join(ran, ",") == "global,app", "case2", t)
+
+	// the guard of the fallback group isn't bypassed
+	w = serve(mux, "GET", "/app/admin/page")
+	assertTrue(w.Code == http.StatusUnauthorized && w.Body.String() == "", "case3", t)
+
+	// the fallback doesn't fall back to itself
+	mux = NewMux("myMux")
+	mux.Group("/app").Fallback(func(c *Context) { c.NotFound() })
+	mux.Start()
+	w = serve(mux, "GET", "/app/page")
+	assertTrue(w.Code == http.StatusNotFound, "case4", t)
+}
+
+func TestMuxURL(t *testing.T) {
+	mux := NewMux("myMux")
+	api := mux.Group("/api")
+	rt := api.Add([]string{"GET"}, "/users/(id)/page(num:uint)", urlHandler).Name("user")
+	api.Add([]string{"GET"}, "/tags/(tag)/(tag)", urlHandler).Name("tags")
+	assertTrue(rt.Url() == "/api/users/(id)/page(num:uint)", "case0", t)
+	assertTrue(mux.Start() == nil, "case0", t)
+
+	u1, err1 := mux.URL("user", "id", "li", "num", 2)
+	assertTrue(err1 == nil && u1 == "/api/users/li/page2", "case1", t)
+	u2, _ := mux.URL("tags", "tag", []string{"a", "b"})
+	assertTrue(u2 == "/api/tags/a/b", "case2", t)
+
+	_, err3 := mux.URL("none")
+	assertTrue(err3 != nil, "case3", t)
+	_, err4 := mux.URL("user", "id")
+	assertTrue(err4 != nil, "case4", t)
+	_, err5 := mux.URL("user", "id", "li", "num", -1)
+	assertTrue(err5 != nil, "case5", t)
+
+	mux.Add([]string{"POST"}, "/users", urlHandler).Name("user")
+	assertTrue(mux.Start() != nil, "case6", t)
+}
+
+func TestMetadata(t *testing.T) {
+	mux := NewMux("myMux")
+	api := mux.Group("/api").Meta("owner", "api").Meta("auth", true)
+	admin := api.Group("/admin").Meta("owner", "admin")
+	rt := admin.Add([]string{"GET"}, "/users", urlHandler).Meta("auth", false)
+
+	owner, _ := rt.Metadata("owner")
+	auth, _ := rt.Metadata("auth")
+	_, ok := rt.Metadata("none")
+	assertTrue(owner == "admin" && auth == false && !ok, "case1", t)
+	owner, _ = api.Metadata("owner")
+	assertTrue(owner == "api", "case1", t)
+
+	var got interface{}
+	var meta router.Metadata
+	mux.Use(func(c *Context) {
+		got, _ = c.Metadata("owner")
+		meta = c.Result.Meta
+	})
+	mux.Start()
+	serve(mux, "GET", "/api/admin/users")
+	assertTrue(got == "admin", "case2", t)
+	assertTrue(meta.String("owner") == "admin" && len(meta) == 2 && !meta.Bool("auth"), "case3", t)
+	serve(mux, "GET", "/none")
+	assertTrue(got == nil && meta == nil, "case2", t)
+
+	// the requests read the metadata resolved at Start
+	rt.Meta("owner", "late")
+	serve(mux, "GET", "/api/admin/users")
+	assertTrue(got == "admin", "case4", t)
+	mux.Start()
+	serve(mux, "GET", "/api/admin/users")
+	assertTrue(got == "late", "case4", t)
+}
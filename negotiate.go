@@ -0,0 +1,206 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"fmt"
+	"github.com/arging/utils/errors"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Formats of content negotiation.
+const (
+	FormatJSON = "json"
+	FormatXML  = "xml"
+	FormatHTML = "html"
+	FormatText = "text"
+)
+
+// The media types of the formats.
+var formatTypes = map[string]string{
+	FormatJSON: "application/json",
+	FormatXML:  "application/xml",
+	FormatHTML: "text/html",
+	FormatText: "text/plain",
+
+	FormatProblem: MIMEProblemJSON,
+}
+
+// The formats of the url suffixes.
+var suffixFormats = map[string]string{
+	".json": FormatJSON,
+	".xml":  FormatXML,
+	".html": FormatHTML,
+	".htm":  FormatHTML,
+	".txt":  FormatText,
+}
+
+// Negotiation is the response for content negotiation.
+type Negotiation struct {
+	Data     interface{} // Data for all formats, text format uses fmt.Sprint
+	Template string      // Template name for HTML format
+
+	// Offered formats in preference order.
+	// Default is json and xml, and html if the Template is set.
+	Offers []string
+}
+
+// Render the response in the best format, see Format.
+// If no offered format fits, it replies 406 Not Acceptable.
+func (c *Context) Negotiate(code int, n Negotiation) error {
+	offers := n.Offers
+	if len(offers) == 0 {
+		offers = []string{FormatJSON, FormatXML}
+		if n.Template != "" {
+			offers = append(offers, FormatHTML)
+		}
+	}
+
+	switch format := c.Format(offers...); format {
+	case FormatJSON:
+		return c.JSON(code, n.Data)
+	case FormatXML:
+		return c.XML(code, n.Data)
+	case FormatHTML:
+		return c.HTML(code, n.Template, n.Data)
+	case FormatText:
+		return c.Text(code, fmt.Sprint(n.Data))
+	case "":
+		c.Error(NewHTTPError(http.StatusNotAcceptable, ""))
+		return nil
+	default:
+		return errors.Newf("negotiate error, unknown format: %s.", format)
+	}
+}
+
+// Get the best format from the offers.
+// The format suffix of the url, such as ".json" and ".xml", is chosen first.
+// Otherwise, the format is chosen by the q-values of the Accept header.
+// If no Accept header, the first offer is chosen.
+// Return empty string, when no offer fits.
+func (c *Context) Format(offers ...string) string {
+	if format := c.suffixFormat(); format != "" {
+		for _, offer := range offers {
+			if offer == format {
+				return format
+			}
+		}
+		return ""
+	}
+
+	c.Response.Header().Add("Vary", "Accept")
+	accept := c.Request.Header.Get("Accept")
+	if accept == "" {
+		if len(offers) > 0 {
+			return offers[0]
+		}
+		return ""
+	}
+
+	// The offer is chosen by its most preferred media range, and the offer
+	// refused by q=0 is excluded, such as "application/json;q=0, */*".
+	specs := parseAccept(accept)
+	best, bestIndex := "", len(specs)
+	for _, offer := range offers {
+		if i := acceptIndex(specs, formatTypes[offer]); i < bestIndex && specs[i].q > 0 {
+			best, bestIndex = offer, i
+		}
+	}
+	return best
+}
+
+// Get the index of the most specific media range which matches the media
+// type, the former wins for the same specificity.
+// Return the length of specs, when no media range matches.
+func acceptIndex(specs []*acceptSpec, mediaType string) int {
+	index := len(specs)
+	for i, spec := range specs {
+		if spec.match(mediaType) && (index == len(specs) || spec.specificity() > specs[index].specificity()) {
+			index = i
+		}
+	}
+	return index
+}
+
+// Get the format of the url suffix.
+// When the Mux strips the suffix for routing, it is recorded in the context.
+func (c *Context) suffixFormat() string {
+	if c.format != "" {
+		return c.format
+	}
+	return suffixFormats[path.Ext(c.Request.URL.Path)]
+}
+
+// Split the format suffix from the url path.
+// Return the origin url and empty format, when the url has no format suffix.
+func splitFormat(url string) (string, string) {
+	ext := path.Ext(url)
+	if format, ok := suffixFormats[ext]; ok && !strings.HasSuffix(url, pathSep+ext) {
+		return url[:len(url)-len(ext)], format
+	}
+	return url, ""
+}
+
+// Defined for a media range of the Accept header.
+type acceptSpec struct {
+	mediaType string // such as "text/html", "text/*" and "*/*"
+	q         float64
+}
+
+// Is the media type in the media range.
+func (s *acceptSpec) match(mediaType string) bool {
+	if s.mediaType == "*/*" || s.mediaType == mediaType {
+		return true
+	}
+	return strings.HasSuffix(s.mediaType, "/*") &&
+		strings.HasPrefix(mediaType, s.mediaType[:len(s.mediaType)-1])
+}
+
+// The specificity of the media range, the precise type is the highest.
+func (s *acceptSpec) specificity() int {
+	if s.mediaType == "*/*" {
+		return 0
+	}
+	if strings.HasSuffix(s.mediaType, "/*") {
+		return 1
+	}
+	return 2
+}
+
+// Parse the Accept header, the media ranges with zero q-value are kept, since
+// they refuse the media types. The result is sorted by q-value, specificity
+// and order in the header.
+func parseAccept(accept string) []*acceptSpec {
+	var specs []*acceptSpec
+	for _, v := range strings.Split(accept, ",") {
+		parts := strings.Split(v, ";")
+		spec := &acceptSpec{strings.ToLower(strings.TrimSpace(parts[0])), 1}
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				q, err := strconv.ParseFloat(param[2:], 64)
+				if err != nil {
+					q = 0
+				}
+				spec.q = q
+			}
+		}
+		if spec.mediaType != "" {
+			specs = append(specs, spec)
+		}
+	}
+
+	sort.SliceStable(specs, func(i, j int) bool {
+		if specs[i].q != specs[j].q {
+			return specs[i].q > specs[j].q
+		}
+		return specs[i].specificity() > specs[j].specificity()
+	})
+	return specs
+}
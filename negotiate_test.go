@@ -0,0 +1,103 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParseAccept(t *testing.T) {
+	specs := parseAccept("text/*;q=0.5, application/json, */*;q=0.1, text/html;q=0.5, image/png;q=0")
+	types := make([]string, len(specs))
+	for i, spec := range specs {
+		types[i] = spec.mediaType
+	}
+	assertTrue(len(types) == 5, "case1", t)
+	assertTrue(types[0] == "application/json" && types[1] == "text/html", "case1", t)
+	assertTrue(types[2] == "text/*" && types[3] == "*/*" && types[4] == "image/png", "case1", t)
+}
+
+func TestContextFormat(t *testing.T) {
+	var format string
+	mux := NewMux("myMux")
+	mux.Add([]string{"GET"}, "/users/(id)", func(c *Context) {
+		format = c.Format(FormatJSON, FormatXML)
+	})
+	mux.Start()
+
+	serve(mux, "GET", "/users/1")
+	assertTrue(format == FormatJSON, "case1", t)
+	serve(mux, "GET", "/users/1", "Accept", "application/xml, application/json;q=0.9")
+	assertTrue(format == FormatXML, "case2", t)
+	serve(mux, "GET", "/users/1", "Accept", "text/*, */*;q=0.1")
+	assertTrue(format == FormatJSON, "case3", t)
+	serve(mux, "GET", "/users/1", "Accept", "text/html")
+	assertTrue(format == "", "case4", t)
+
+	// refused by q=0
+	serve(mux, "GET", "/users/1", "Accept", "application/json;q=0, */*")
+	assertTrue(format == FormatXML, "case7", t)
+	serve(mux, "GET", "/users/1", "Accept", "*/*, application/*;q=0")
+	assertTrue(format == "", "case7", t)
+	serve(mux, "GET", "/users/1", "Accept", "application/*;q=0, application/xml;q=0.5")
+	assertTrue(format == FormatXML, "case7", t)
+
+	// suffix wins
+	serve(mux, "GET", "/users/1.xml", "Accept", "application/json")
+	assertTrue(format == FormatXML, "case5", t)
+	serve(mux, "GET", "/users/1.html")
+	assertTrue(format == "", "case6", t)
+}
+
+func TestMuxFormatSuffix(t *testing.T) {
+	var id, format string
+	mux := NewMux("myMux")
+	mux.FormatSuffix = true
+	mux.Add([]string{"GET"}, "/users/(id)", func(c *Context) {
+		id, format = c.Param("id"), c.Format(FormatJSON, FormatXML)
+	})
+	mux.Start()
+
+	serve(mux, "GET", "/users/1.xml")
+	assertTrue(id == "1" && format == FormatXML, "case1", t)
+	serve(mux, "GET", "/users/1.png")
+	assertTrue(id == "1.png" && format == FormatJSON, "case2", t)
+	serve(mux, "GET", "/users/1")
+	assertTrue(id == "1" && format == FormatJSON, "case3", t)
+
+	url, format := splitFormat("/users/.json")
+	assertTrue(url == "/users/.json" && format == "", "case4", t)
+}
+
+func TestNegotiate(t *testing.T) {
+	mux := NewMux("myMux")
+	mux.FormatSuffix = true
+	mux.Add([]string{"GET"}, "/users/(id)", func(c *Context) {
+		c.Negotiate(http.StatusOK, Negotiation{Data: &renderUser{"li"}})
+	})
+	mux.Add([]string{"GET"}, "/text", func(c *Context) {
+		c.Negotiate(http.StatusOK, Negotiation{Data: "hello", Offers: []string{FormatText}})
+	})
+	mux.Start()
+
+	w1 := serve(mux, "GET", "/users/1.json")
+	assertTrue(w1.Header().Get("Content-Type") == MIMEJSON, "case1", t)
+	assertTrue(w1.Body.String() == `{"name":"li"}`, "case1", t)
+
+	w2 := serve(mux, "GET", "/users/1", "Accept", "application/xml")
+	assertTrue(w2.Header().Get("Content-Type") == MIMEXML, "case2", t)
+	assertTrue(w2.Header().Get("Vary") == "Accept", "case2", t)
+
+	w3 := serve(mux, "GET", "/users/1", "Accept", "text/html")
+	assertTrue(w3.Code == http.StatusNotAcceptable, "case3", t)
+	w3 = serve(mux, "GET", "/users/1", "Accept", "text/html, application/problem+json")
+	assertTrue(w3.Code == http.StatusNotAcceptable && w3.Header().Get("Content-Type") == MIMEProblemJSON, "case3", t)
+	w4 := serve(mux, "GET", "/users/1.html")
+	assertTrue(w4.Code == http.StatusNotAcceptable, "case4", t)
+
+	w5 := serve(mux, "GET", "/text", "Accept", "text/*")
+	assertTrue(w5.Body.String() == "hello" && w5.Header().Get("Content-Type") == MIMEText, "case5", t)
+}
@@ -0,0 +1,100 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// The content type of problem details, see RFC 7807.
+const MIMEProblemJSON = "application/problem+json"
+
+// The format of problem details for content negotiation.
+const FormatProblem = "problem"
+
+// The member names of problem details.
+const (
+	problemType     = "type"
+	problemTitle    = "title"
+	problemStatus   = "status"
+	problemDetail   = "detail"
+	problemInstance = "instance"
+	problemRoute    = "route"  // Extension member for the matched route pattern
+	problemErrors   = "errors" // Extension member for the HTTPError details
+)
+
+// Problem is the problem details for HTTP APIs, see RFC 7807.
+// The extension members are marshaled at the same level as the standard
+// members, and they can't override the standard members.
+type Problem struct {
+	Type       string                 // A URI identifies the problem type, default is "about:blank"
+	Title      string                 // A short summary of the problem type
+	Status     int                    // The status code
+	Detail     string                 // The explanation of this occurrence
+	Instance   string                 // A URI identifies this occurrence
+	Extensions map[string]interface{} // The extension members
+}
+
+// Create the problem details of the HTTPError for the request.
+// The detail is the public message unless it is the status text, and the
+// "errors" extension member is the public details, such as the field errors.
+// The instance is the request path, and the "route" extension member is the
+// matched route pattern.
+func NewProblem(c *Context, e *HTTPError) *Problem {
+	p := &Problem{
+		Type:       e.Type,
+		Title:      http.StatusText(e.Code),
+		Status:     e.Code,
+		Instance:   c.Request.URL.Path,
+		Extensions: make(map[string]interface{}),
+	}
+	if e.Message != p.Title {
+		p.Detail = e.Message
+	}
+	if c.Result != nil && c.Result.IsMatch {
+		p.Extensions[problemRoute] = c.Result.Url
+	}
+	if e.Details != nil {
+		p.Extensions[problemErrors] = e.Details
+	}
+	return p
+}
+
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		members[k] = v
+	}
+
+	members[problemType] = p.Type
+	if p.Type == "" {
+		members[problemType] = "about:blank"
+	}
+	if p.Title != "" {
+		members[problemTitle] = p.Title
+	}
+	if p.Status != 0 {
+		members[problemStatus] = p.Status
+	}
+	if p.Detail != "" {
+		members[problemDetail] = p.Detail
+	}
+	if p.Instance != "" {
+		members[problemInstance] = p.Instance
+	}
+	return json.Marshal(members)
+}
+
+// Render the problem details with its status code.
+func (c *Context) Problem(p *Problem) error {
+	code := p.Status
+	if code == 0 {
+		code = http.StatusInternalServerError
+	}
+	return c.render(code, MIMEProblemJSON, func() ([]byte, error) {
+		return json.Marshal(p)
+	})
+}
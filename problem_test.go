@@ -0,0 +1,62 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestProblemMarshal(t *testing.T) {
+	p1 := &Problem{Status: 404, Title: "Not Found"}
+	data1, _ := json.Marshal(p1)
+	assertTrue(string(data1) == `{"status":404,"title":"Not Found","type":"about:blank"}`, "case1", t)
+
+	p2 := &Problem{
+		Type:       "https://example.com/probs/out-of-credit",
+		Status:     403,
+		Detail:     "balance is 30",
+		Instance:   "/account/12",
+		Extensions: map[string]interface{}{"balance": 30, "status": 200},
+	}
+	data2, _ := json.Marshal(p2)
+	expected := `{"balance":30,"detail":"balance is 30","instance":"/account/12",` +
+		`"status":403,"type":"https://example.com/probs/out-of-credit"}`
+	assertTrue(string(data2) == expected, "case2", t)
+}
+
+func TestProblemErrorHandler(t *testing.T) {
+	mux := NewMux("myMux")
+	mux.Add([]string{"POST"}, "/users/(id)", func(c *Context) {
+		fields := map[string]string{"Name": "Name is required"}
+		c.Error(NewHTTPError(http.StatusUnprocessableEntity, "invalid user").
+			WithDetails(fields).WithType("https://example.com/probs/invalid"))
+	})
+	mux.Start()
+
+	// content negotiation
+	w1 := serve(mux, "POST", "/users/1", "Accept", "application/problem+json, application/json;q=0.9")
+	assertTrue(w1.Code == http.StatusUnprocessableEntity, "case1", t)
+	assertTrue(w1.Header().Get("Content-Type") == MIMEProblemJSON, "case1", t)
+
+	var doc map[string]interface{}
+	json.Unmarshal(w1.Body.Bytes(), &doc)
+	assertTrue(doc["type"] == "https://example.com/probs/invalid", "case2", t)
+	assertTrue(doc["title"] == "Unprocessable Entity" && doc["detail"] == "invalid user", "case2", t)
+	assertTrue(doc["status"] == float64(422), "case2", t)
+	assertTrue(doc["instance"] == "/users/1" && doc["route"] == "/users/(id)", "case2", t)
+	assertTrue(doc["errors"].(map[string]interface{})["Name"] == "Name is required", "case2", t)
+
+	w2 := serve(mux, "POST", "/users/1", "Accept", "application/json")
+	assertTrue(w2.Header().Get("Content-Type") == MIMEJSON, "case3", t)
+
+	// configuration
+	mux.ProblemDetails = true
+	w3 := serve(mux, "GET", "/none")
+	assertTrue(w3.Code == http.StatusNotFound, "case4", t)
+	assertTrue(w3.Header().Get("Content-Type") == MIMEProblemJSON, "case4", t)
+	assertTrue(w3.Body.String() == `{"instance":"/none","status":404,"title":"Not Found","type":"about:blank"}`, "case4", t)
+}
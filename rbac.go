@@ -0,0 +1,160 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"net/http"
+	"strings"
+)
+
+// The metadata keys of the access rules, they are set by RequireRoles and
+// RequirePermissions of routes and groups, and checked by Authorize.
+const (
+	MetaRoles       = "auth.roles"
+	MetaPermissions = "auth.permissions"
+)
+
+// The context key marks that Authorize has run, so the fallback of a missing
+// file checks the rules of its groups again.
+const authorizedKey = "light.authorized"
+
+// Authorize checks the access rules of the matched route against the
+// principal of the auth middleware, so it must run after them:
+//
+//	mux.Use(light.JWTAuth(config), light.Authorize())
+//	admin := mux.Group("/admin").RequireRoles("admin")
+//	admin.Add([]string{"DELETE"}, "/users/(id)", deleteUser).RequirePermissions("users:delete")
+//
+// The principal must have one of the required roles, and all the required
+// permissions. The request is replied 401 if it isn't authenticated, and 403
+// if the principal isn't allowed. The routes without rules, and the routes
+// with metadata MetaAuthSkip, are not checked.
+func Authorize() HandlerFunc {
+	return func(c *Context) {
+		c.Set(authorizedKey, true)
+		authorize(c)
+	}
+}
+
+// Check the access rules of the context metadata.
+func authorize(c *Context) {
+	if c.skipAuth() {
+		return
+	}
+	roles, perms := c.meta.Strings(MetaRoles), c.meta.Strings(MetaPermissions)
+	if len(roles) == 0 && len(perms) == 0 {
+		return
+	}
+
+	p := c.Principal()
+	if p == nil {
+		c.Error(NewHTTPError(http.StatusUnauthorized, "authentication required"))
+		return
+	}
+	if !p.Allowed(roles, perms) {
+		c.Error(NewHTTPError(http.StatusForbidden, "permission denied"))
+	}
+}
+
+// Is the principal allowed by the rules, it has one of the roles, and all
+// the permissions. The empty roles or permissions are not checked.
+func (p *Principal) Allowed(roles []string, permissions []string) bool {
+	if len(roles) > 0 {
+		ok := false
+		for _, role := range roles {
+			if p.HasRole(role) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	for _, perm := range permissions {
+		if !p.HasPermission(perm) {
+			return false
+		}
+	}
+	return true
+}
+
+// Does the principal have the role.
+func (p *Principal) HasRole(role string) bool {
+	return containsString(p.Roles, role)
+}
+
+// Does the principal have the permission.
+func (p *Principal) HasPermission(permission string) bool {
+	return containsString(p.Permissions, permission)
+}
+
+// Require one of the roles for the routes of the group, see Authorize.
+// The routes and sub groups inherit them, unless they set their own.
+func (g *RouteGroup) RequireRoles(roles ...string) *RouteGroup {
+	return g.Meta(MetaRoles, roles)
+}
+
+// Require all the permissions for the routes of the group, see Authorize.
+// The routes and sub groups inherit them, unless they set their own.
+func (g *RouteGroup) RequirePermissions(permissions ...string) *RouteGroup {
+	return g.Meta(MetaPermissions, permissions)
+}
+
+// Require one of the roles for the route, see Authorize.
+// It overrides the roles of the groups.
+func (rt *Route) RequireRoles(roles ...string) *Route {
+	return rt.Meta(MetaRoles, roles)
+}
+
+// Require all the permissions for the route, see Authorize.
+// It overrides the permissions of the groups.
+func (rt *Route) RequirePermissions(permissions ...string) *Route {
+	return rt.Meta(MetaPermissions, permissions)
+}
+
+// Get the roles required by the route, including the inherited ones.
+// Return nil, when no role is required.
+func (rt *Route) RequiredRoles() []string {
+	roles, _ := rt.Metadata(MetaRoles)
+	strs, _ := roles.([]string)
+	return strs
+}
+
+// Get the permissions required by the route, including the inherited ones.
+// Return nil, when no permission is required.
+func (rt *Route) RequiredPermissions() []string {
+	perms, _ := rt.Metadata(MetaPermissions)
+	strs, _ := perms.([]string)
+	return strs
+}
+
+// Get the access rules of the route for review, such as
+// "roles=admin|editor permissions=users:read,users:write", the roles are
+// separated by "|" since one of them is required. Return "public" for the
+// routes with metadata MetaAuthSkip, and empty string for no rules.
+func (rt *Route) Access() string {
+	if skip, _ := rt.Metadata(MetaAuthSkip); skip == true {
+		return "public"
+	}
+	var rules []string
+	if roles := rt.RequiredRoles(); len(roles) > 0 {
+		rules = append(rules, "roles="+strings.Join(roles, "|"))
+	}
+	if perms := rt.RequiredPermissions(); len(perms) > 0 {
+		rules = append(rules, "permissions="+strings.Join(perms, ","))
+	}
+	return strings.Join(rules, " ")
+}
+
+// Is the string in the slice.
+func containsString(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"net/http"
+	"testing"
+)
+
+func rbacMux() *Mux {
+	users := map[string]*Principal{
+		"admin":  {Roles: []string{"admin"}, Permissions: []string{"users:read", "users:delete"}},
+		"editor": {Roles: []string{"editor"}, Permissions: []string{"users:read"}},
+	}
+	mux := NewMux("myMux")
+	mux.Use(APIKeyAuth(APIKeyConfig{Verify: func(key string) *Principal {
+		return users[key]
+	}}), Authorize())
+	mux.Add([]string{"GET"}, "/health", urlHandler).Meta(MetaAuthSkip, true)
+	mux.Add([]string{"GET"}, "/me", urlHandler)
+
+	admin := mux.Group("/admin").RequireRoles("admin", "editor").RequirePermissions("users:read")
+	admin.Add([]string{"GET"}, "/users", urlHandler)
+	admin.Add([]string{"DELETE"}, "/users/(id)", urlHandler).RequirePermissions("users:delete")
+	admin.Group("/audit").RequireRoles("admin").Add([]string{"GET"}, "/logs", urlHandler)
+	mux.Start()
+	return mux
+}
+
+func TestAuthorize(t *testing.T) {
+	mux := rbacMux()
+
+	cases := []struct {
+		method, url, key string
+		code             int
+	}{
+		{"GET", "/health", "", http.StatusOK},
+		{"GET", "/me", "editor", http.StatusOK},
+		{"GET", "/admin/users", "editor", http.StatusOK},
+		{"GET", "/admin/users", "admin", http.StatusOK},
+		{"DELETE", "/admin/users/1", "admin", http.StatusOK},
+		{"DELETE", "/admin/users/1", "editor", http.StatusForbidden},
+		{"GET", "/admin/audit/logs", "admin", http.StatusOK},
+		{"GET", "/admin/audit/logs", "editor", http.StatusForbidden},
+		{"GET", "/admin/users", "", http.StatusUnauthorized},
+	}
+	for i, cs := range cases {
+		w := serve(mux, cs.method, cs.url, "X-API-Key", cs.key)
+		if w.Code != cs.code {
+			t.Errorf("case%d: %s %s %d", i+1, cs.method, cs.url, w.Code)
+		}
+	}
+
+	// Authorize alone replies 401 without principal
+	mux = NewMux("myMux")
+	mux.Use(Authorize())
+	mux.Add([]string{"GET"}, "/admin", urlHandler).RequireRoles("admin")
+	mux.Add([]string{"GET"}, "/open", urlHandler)
+	mux.Start()
+	assertTrue(serve(mux, "GET", "/admin").Code == http.StatusUnauthorized, "case10", t)
+	assertTrue(serve(mux, "GET", "/open").Code == http.StatusOK, "case10", t)
+
+	// the fallback checks the rules of its groups
+	mux = NewMux("myMux")
+	mux.Use(APIKeyAuth(APIKeyConfig{Verify: func(key string) *Principal {
+		return &Principal{Roles: []string{key}}
+	}}), Authorize())
+	mux.Group("/admin").RequireRoles("admin").Fallback(urlHandler)
+	mux.Add([]string{"GET"}, "/admin/files/(name)", func(c *Context) { c.NotFound() })
+	mux.Start()
+	assertTrue(serve(mux, "GET", "/admin/dashboard").Code == http.StatusUnauthorized, "case11", t)
+	assertTrue(serve(mux, "GET", "/admin/dashboard", "X-API-Key", "editor").Code == http.StatusForbidden, "case11", t)
+	assertTrue(serve(mux, "GET", "/admin/dashboard", "X-API-Key", "admin").Code == http.StatusOK, "case11", t)
+	assertTrue(serve(mux, "GET", "/admin/files/a", "X-API-Key", "editor").Code == http.StatusForbidden, "case12", t)
+	assertTrue(serve(mux, "GET", "/admin/files/a", "X-API-Key", "admin").Code == http.StatusOK, "case12", t)
+}
+
+func TestAccess(t *testing.T) {
+	mux := rbacMux()
+	access := make(map[string]string)
+	for _, rt := range mux.Routes() {
+		access[rt.Url()] = rt.Access()
+	}
+	assertTrue(access["/health"] == "public", "case1", t)
+	assertTrue(access["/me"] == "", "case2", t)
+	assertTrue(access["/admin/users"] == "roles=admin|editor permissions=users:read", "case3", t)
+	assertTrue(access["/admin/users/(id)"] == "roles=admin|editor permissions=users:delete", "case4", t)
+	assertTrue(access["/admin/audit/logs"] == "roles=admin permissions=users:read", "case5", t)
+
+	p := &Principal{Roles: []string{"dev"}, Permissions: []string{"a", "b"}}
+	assertTrue(p.Allowed(nil, nil) && p.Allowed([]string{"ops", "dev"}, []string{"a", "b"}), "case6", t)
+	assertTrue(!p.Allowed([]string{"ops"}, nil) && !p.Allowed(nil, []string{"a", "c"}), "case6", t)
+}
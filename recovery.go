@@ -0,0 +1,185 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/arging/utils/errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+// Recovery recovers the panics of the next handlers, and replies 500.
+// It should be the first global middleware, then the panics of routing
+// are also recovered.
+//
+// If the Debug of the Mux is set, it shows an HTML page with the stack trace,
+// the matched route, the params and the request details. Otherwise, the panic
+// is replied by the ErrorHandler, which logs the panic with the stack trace.
+func Recovery() HandlerFunc {
+	return func(c *Context) {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			// The handler aborts the response on purpose.
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+
+			stack := debug.Stack()
+			err := errors.Newf("panic recovered: %v\n%s", p, stack)
+			if !c.mux.Debug {
+				c.Error(err)
+				return
+			}
+
+			c.Abort()
+			c.mux.Logger.Print(err)
+			if !c.Response.Written() {
+				c.debugPage(p, stack)
+			}
+		}()
+		c.Next()
+	}
+}
+
+// Defined for the data of debug page.
+type debugData struct {
+	Panic   string
+	Stack   string
+	Method  string
+	Url     string
+	Route   string
+	Params  map[string][]string
+	Headers []string
+}
+
+// The headers hidden in the debug page, in canonical form. The headers of
+// the auth and CSRF middleware are also hidden, see hideHeader.
+var secretHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"X-Api-Key":           true,
+	"X-Auth-Token":        true,
+	"X-Csrf-Token":        true,
+	"X-Xsrf-Token":        true,
+}
+
+// The context keys of the secret headers and query params of the request.
+const (
+	secretHeadersKey = "light.secretHeaders"
+	secretQueriesKey = "light.secretQueries"
+)
+
+// Hide the request header in the debug page, such as the configured header
+// of API key.
+func (c *Context) hideHeader(name string) {
+	name = http.CanonicalHeaderKey(name)
+	if !secretHeaders[name] {
+		c.hide(secretHeadersKey, name)
+	}
+}
+
+// Hide the query param in the debug page and the access log, such as the
+// configured query param of API key.
+func (c *Context) hideQuery(name string) {
+	c.hide(secretQueriesKey, name)
+}
+
+// Add the name into the secret names of the context key.
+func (c *Context) hide(key string, name string) {
+	names := c.secrets(key)
+	if names == nil {
+		names = make(map[string]bool)
+		c.Set(key, names)
+	}
+	names[name] = true
+}
+
+// Get the secret names of the context key.
+func (c *Context) secrets(key string) map[string]bool {
+	hidden, _ := c.Get(key)
+	names, _ := hidden.(map[string]bool)
+	return names
+}
+
+// Mask the values of the secret query params in the request uri.
+func (c *Context) maskURI(uri string) string {
+	names := c.secrets(secretQueriesKey)
+	i := strings.IndexByte(uri, '?')
+	if len(names) == 0 || i < 0 {
+		return uri
+	}
+
+	params := strings.Split(uri[i+1:], "&")
+	for j, param := range params {
+		name := param
+		if k := strings.IndexByte(param, '='); k >= 0 {
+			name = param[:k]
+		}
+		if key, err := url.QueryUnescape(name); err == nil && names[key] {
+			params[j] = name + "=******"
+		}
+	}
+	return uri[:i+1] + strings.Join(params, "&")
+}
+
+var debugTemplate = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>500 Internal Server Error</title></head>
+<body style="font-family:monospace">
+<h1>panic: {{.Panic}}</h1>
+<h2>Request</h2>
+<p>{{.Method}} {{.Url}}</p>
+<h2>Route</h2>
+<p>{{if .Route}}{{.Route}}{{else}}no matched route{{end}}</p>
+<h2>Params</h2>
+<ul>{{range $k, $v := .Params}}<li>{{$k}} = {{$v}}</li>{{end}}</ul>
+<h2>Headers</h2>
+<ul>{{range .Headers}}<li>{{.}}</li>{{end}}</ul>
+<h2>Stack</h2>
+<pre>{{.Stack}}</pre>
+</body>
+</html>
+`))
+
+// Render the debug page of the panic.
+func (c *Context) debugPage(p interface{}, stack []byte) {
+	data := &debugData{
+		Panic:  fmt.Sprint(p),
+		Stack:  string(stack),
+		Method: c.Request.Method,
+		Url:    c.maskURI(c.Request.URL.String()),
+	}
+	if c.Result != nil {
+		data.Route = c.Result.Url
+		data.Params = c.Result.Params
+	}
+	names := c.secrets(secretHeadersKey)
+	for name, values := range c.Request.Header {
+		for _, value := range values {
+			if secretHeaders[name] || names[name] {
+				value = "******"
+			}
+			data.Headers = append(data.Headers, name+": "+value)
+		}
+	}
+	sort.Strings(data.Headers)
+
+	var buf bytes.Buffer
+	if err := debugTemplate.Execute(&buf, data); err != nil {
+		c.mux.Logger.Printf("render debug page error: %v", err)
+		return
+	}
+	c.Data(http.StatusInternalServerError, MIMEHTML, buf.Bytes())
+}
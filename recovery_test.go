@@ -0,0 +1,115 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"bytes"
+	"github.com/arging/utils/errors"
+	"github.com/uestcer/light/router"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// Router panics for routing.
+type panicRouter struct {
+	router.Router
+}
+
+func (r *panicRouter) Route(method string, url string) *router.Result {
+	panic("Never happen!")
+}
+
+func recoveryMux(logs *bytes.Buffer) *Mux {
+	mux := NewMux("myMux")
+	mux.Logger = log.New(logs, "", 0)
+	mux.Use(Recovery())
+	mux.Add([]string{"GET"}, "/users/(id)", func(c *Context) {
+		panic("user <" + c.Param("id") + ">")
+	})
+	mux.Start()
+	return mux
+}
+
+func TestRecovery(t *testing.T) {
+	var logs bytes.Buffer
+	mux := recoveryMux(&logs)
+
+	w1 := serve(mux, "GET", "/users/1", "Accept", "application/json")
+	assertTrue(w1.Code == http.StatusInternalServerError, "case1", t)
+	assertTrue(w1.Body.String() == `{"error":"Internal Server Error"}`, "case1", t)
+	assertTrue(strings.Contains(logs.String(), "panic recovered: user <1>"), "case1", t)
+	assertTrue(strings.Contains(logs.String(), "recovery_test.go"), "case1", t)
+}
+
+func TestRecoveryDebug(t *testing.T) {
+	var logs bytes.Buffer
+	mux := recoveryMux(&logs)
+	mux.Debug = true
+
+	w1 := serve(mux, "GET", "/users/1?q=x", "X-Name", "li", "Cookie", "sid=secret")
+	body := w1.Body.String()
+	assertTrue(w1.Code == http.StatusInternalServerError, "case1", t)
+	assertTrue(w1.Header().Get("Content-Type") == MIMEHTML, "case1", t)
+	assertTrue(strings.Contains(body, "panic: user &lt;1&gt;"), "case2", t)
+	assertTrue(strings.Contains(body, "GET /users/1?q=x"), "case2", t)
+	assertTrue(strings.Contains(body, "/users/(id)"), "case2", t)
+	assertTrue(strings.Contains(body, "id = [1]"), "case2", t)
+	assertTrue(strings.Contains(body, "X-Name: li"), "case2", t)
+	assertFalse(strings.Contains(body, "secret"), "case2", t)
+	assertTrue(strings.Contains(body, "recovery_test.go"), "case2", t)
+	assertTrue(strings.Contains(logs.String(), "panic recovered"), "case3", t)
+}
+
+func TestRecoverySecretHeaders(t *testing.T) {
+	mux := NewMux("myMux")
+	mux.Logger = log.New(&bytes.Buffer{}, "", 0)
+	mux.Debug = true
+	mux.Use(Recovery(), APIKeyAuth(APIKeyConfig{Header: "X-Token", Query: "api_key", Verify: func(key string) *Principal {
+		return &Principal{}
+	}}))
+	mux.Add([]string{"GET"}, "/panic", func(c *Context) {
+		panic("boom")
+	})
+	mux.Start()
+
+	w := serve(mux, "GET", "/panic", "X-Token", "secret1", "X-API-Key", "secret2",
+		"X-CSRF-Token", "secret3", "X-Name", "li")
+	body := w.Body.String()
+	assertTrue(w.Code == http.StatusInternalServerError && strings.Contains(body, "X-Name: li"), "case1", t)
+	assertTrue(strings.Contains(body, "X-Token: ******"), "case1", t)
+	assertFalse(strings.Contains(body, "secret"), "case1", t)
+
+	// the query param of API key is hidden
+	w = serve(mux, "GET", "/panic?x=1&api_key=secret4")
+	body = w.Body.String()
+	assertTrue(strings.Contains(body, "/panic?x=1&amp;api_key=******"), "case2", t)
+	assertFalse(strings.Contains(body, "secret"), "case2", t)
+}
+
+func TestRecoveryRouting(t *testing.T) {
+	var logs bytes.Buffer
+	mux := recoveryMux(&logs)
+	mux.router = &panicRouter{mux.router}
+
+	w1 := serve(mux, "GET", "/users/1")
+	assertTrue(w1.Code == http.StatusInternalServerError, "case1", t)
+	assertTrue(strings.Contains(logs.String(), "Never happen!"), "case1", t)
+}
+
+func TestRecoveryAfterError(t *testing.T) {
+	mux := NewMux("myMux")
+	mux.Logger = log.New(&bytes.Buffer{}, "", 0)
+	mux.Use(Recovery())
+	mux.Add([]string{"GET"}, "/", func(c *Context) {
+		c.Error(NewHTTPError(http.StatusBadRequest, "bad"))
+		panic(errors.New("after error"))
+	})
+	mux.Start()
+
+	w1 := serve(mux, "GET", "/")
+	assertTrue(w1.Code == http.StatusBadRequest && w1.Body.String() == "bad", "case1", t)
+}
@@ -0,0 +1,147 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"github.com/arging/utils/errors"
+	"io"
+	"net/http"
+	"regexp"
+)
+
+// Content types of the rendered responses.
+const (
+	MIMEJSON       = "application/json; charset=utf-8"
+	MIMEJavaScript = "application/javascript; charset=utf-8"
+	MIMEXML        = "application/xml; charset=utf-8"
+	MIMEText       = "text/plain; charset=utf-8"
+	MIMEHTML       = "text/html; charset=utf-8"
+	MIMEBinary     = "application/octet-stream"
+)
+
+// The query param name of JSONP callback.
+const callbackParam = "callback"
+
+// The valid JSONP callback, such as "jQuery123_456" and "app.render".
+var callbackRegex = regexp.MustCompile(`^[a-zA-Z_$][a-zA-Z0-9_$]*(\.[a-zA-Z_$][a-zA-Z0-9_$]*)*$`)
+
+// View renders the named template, such as the HTML template engine of the
+// light/view package.
+type View interface {
+	Render(w io.Writer, name string, data interface{}) error
+}
+
+// Render the named template of the Mux View as HTML with the status code.
+func (c *Context) HTML(code int, name string, data interface{}) error {
+	return c.render(code, MIMEHTML, func() ([]byte, error) {
+		if c.mux.View == nil {
+			return nil, errors.Newf("no view for template: %s.", name)
+		}
+		var buf bytes.Buffer
+		if err := c.mux.View.Render(&buf, name, data); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	})
+}
+
+// Render v as JSON with the status code.
+func (c *Context) JSON(code int, v interface{}) error {
+	return c.render(code, MIMEJSON, func() ([]byte, error) {
+		return json.Marshal(v)
+	})
+}
+
+// Render v as indented JSON with the status code.
+func (c *Context) IndentedJSON(code int, v interface{}) error {
+	return c.render(code, MIMEJSON, func() ([]byte, error) {
+		return json.MarshalIndent(v, "", "    ")
+	})
+}
+
+// Render v as JSONP with the status code, the callback is the "callback"
+// query param. If the callback is empty, it renders JSON instead.
+// If the callback is invalid, it replies 400 by the ErrorHandler.
+func (c *Context) JSONP(code int, v interface{}) error {
+	callback := c.Query(callbackParam)
+	if callback == "" {
+		return c.JSON(code, v)
+	}
+	if !callbackRegex.MatchString(callback) {
+		err := NewHTTPError(http.StatusBadRequest, "invalid JSONP callback")
+		c.Error(err)
+		return err
+	}
+
+	return c.render(code, MIMEJavaScript, func() ([]byte, error) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, 0, len(callback)+len(data)+3)
+		buf = append(append(buf, callback...), '(')
+		return append(append(buf, data...), ')', ';'), nil
+	})
+}
+
+// Render v as XML with the status code.
+func (c *Context) XML(code int, v interface{}) error {
+	return c.render(code, MIMEXML, func() ([]byte, error) {
+		data, err := xml.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(xml.Header), data...), nil
+	})
+}
+
+// Render the plain text with the status code.
+func (c *Context) Text(code int, text string) error {
+	return c.Data(code, MIMEText, []byte(text))
+}
+
+// Render the bytes with the content type and status code.
+func (c *Context) Data(code int, contentType string, data []byte) error {
+	return c.render(code, contentType, func() ([]byte, error) {
+		return data, nil
+	})
+}
+
+// Render the content of the reader with the content type and status code.
+// The error of reading can't change the status, which is already written.
+func (c *Context) Stream(code int, contentType string, r io.Reader) error {
+	c.writeHeader(code, contentType)
+	if _, err := io.Copy(c.Response, r); err != nil {
+		return errors.Wrapf(err, "render stream error.")
+	}
+	return nil
+}
+
+// Serialize the content and write it to the response.
+// If serialization failed, the error is replied by Context.Error.
+func (c *Context) render(code int, contentType string, serialize func() ([]byte, error)) error {
+	data, err := serialize()
+	if err != nil {
+		err = errors.Wrapf(err, "render %s error.", contentType)
+		c.Error(err)
+		return err
+	}
+
+	c.writeHeader(code, contentType)
+	if _, err := c.Response.Write(data); err != nil {
+		return errors.Wrapf(err, "render %s error.", contentType)
+	}
+	return nil
+}
+
+// Write the content type and status code.
+func (c *Context) writeHeader(code int, contentType string) {
+	c.Response.Header().Set("Content-Type", contentType)
+	c.Status(code)
+}
@@ -0,0 +1,122 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type renderUser struct {
+	Name string `json:"name" xml:"name"`
+}
+
+// Serve a request to the render handler and return the recorded response.
+func renderRequest(url string, render func(c *Context) error) (*httptest.ResponseRecorder, error) {
+	var err error
+	mux := NewMux("myMux")
+	mux.Add([]string{"GET"}, "/render", func(c *Context) {
+		err = render(c)
+	})
+	mux.Start()
+	return serve(mux, "GET", url), err
+}
+
+func TestRenderJSON(t *testing.T) {
+	w1, err1 := renderRequest("/render", func(c *Context) error {
+		return c.JSON(http.StatusCreated, &renderUser{"li"})
+	})
+	assertTrue(err1 == nil && w1.Code == http.StatusCreated, "case1", t)
+	assertTrue(w1.Header().Get("Content-Type") == MIMEJSON, "case1", t)
+	assertTrue(w1.Body.String() == `{"name":"li"}`, "case1", t)
+
+	w2, _ := renderRequest("/render", func(c *Context) error {
+		return c.IndentedJSON(http.StatusOK, &renderUser{"li"})
+	})
+	assertTrue(w2.Body.String() == "{\n    \"name\": \"li\"\n}", "case2", t)
+}
+
+func TestRenderJSONP(t *testing.T) {
+	jsonp := func(c *Context) error {
+		return c.JSONP(http.StatusOK, &renderUser{"li"})
+	}
+
+	w1, _ := renderRequest("/render?callback=app.show", jsonp)
+	assertTrue(w1.Header().Get("Content-Type") == MIMEJavaScript, "case1", t)
+	assertTrue(w1.Body.String() == `app.show({"name":"li"});`, "case1", t)
+
+	w2, _ := renderRequest("/render", jsonp)
+	assertTrue(w2.Header().Get("Content-Type") == MIMEJSON, "case2", t)
+
+	w3, err3 := renderRequest("/render?callback=alert(1)", jsonp)
+	assertTrue(err3 != nil && w3.Code == http.StatusBadRequest, "case3", t)
+	assertTrue(strings.Contains(w3.Body.String(), "invalid JSONP callback"), "case3", t)
+}
+
+func TestRenderXML(t *testing.T) {
+	w1, _ := renderRequest("/render", func(c *Context) error {
+		return c.XML(http.StatusOK, &renderUser{"li"})
+	})
+	assertTrue(w1.Header().Get("Content-Type") == MIMEXML, "case1", t)
+	assertTrue(strings.HasSuffix(w1.Body.String(), "<renderUser><name>li</name></renderUser>"), "case1", t)
+}
+
+func TestRenderText(t *testing.T) {
+	w1, _ := renderRequest("/render", func(c *Context) error {
+		return c.Text(http.StatusAccepted, "hello")
+	})
+	assertTrue(w1.Code == http.StatusAccepted && w1.Body.String() == "hello", "case1", t)
+	assertTrue(w1.Header().Get("Content-Type") == MIMEText, "case1", t)
+
+	w2, _ := renderRequest("/render", func(c *Context) error {
+		return c.Data(http.StatusOK, "image/png", []byte{1, 2})
+	})
+	assertTrue(w2.Header().Get("Content-Type") == "image/png" && w2.Body.Len() == 2, "case2", t)
+
+	w3, _ := renderRequest("/render", func(c *Context) error {
+		return c.Stream(http.StatusOK, MIMEBinary, strings.NewReader("stream"))
+	})
+	assertTrue(w3.Header().Get("Content-Type") == MIMEBinary && w3.Body.String() == "stream", "case3", t)
+}
+
+func TestRenderError(t *testing.T) {
+	w1, err1 := renderRequest("/render", func(c *Context) error {
+		return c.JSON(http.StatusOK, map[string]interface{}{"ch": make(chan int)})
+	})
+	assertTrue(err1 != nil, "case1", t)
+	assertTrue(w1.Code == http.StatusInternalServerError, "case1", t)
+	assertFalse(strings.Contains(w1.Body.String(), "chan"), "case1", t)
+}
+
+func TestRenderHTML(t *testing.T) {
+	mux := NewMux("myMux")
+	mux.Add([]string{"GET"}, "/users/(id)", func(c *Context) {}).Name("user")
+	mux.Add([]string{"GET"}, "/html", func(c *Context) {
+		c.HTML(http.StatusOK, "user", 12)
+	})
+	mux.Start()
+
+	w1 := serve(mux, "GET", "/html")
+	assertTrue(w1.Code == http.StatusInternalServerError, "case1", t)
+
+	tmpl := template.Must(template.New("user").Funcs(template.FuncMap{"url": mux.URL}).
+		Parse(`<a href="{{url "user" "id" .}}">user</a>`))
+	mux.View = viewFunc(func(w io.Writer, name string, data interface{}) error {
+		return tmpl.ExecuteTemplate(w, name, data)
+	})
+	w2 := serve(mux, "GET", "/html")
+	assertTrue(w2.Code == http.StatusOK && w2.Header().Get("Content-Type") == MIMEHTML, "case2", t)
+	assertTrue(w2.Body.String() == `<a href="/users/12">user</a>`, "case2", t)
+}
+
+type viewFunc func(w io.Writer, name string, data interface{}) error
+
+func (f viewFunc) Render(w io.Writer, name string, data interface{}) error {
+	return f(w, name, data)
+}
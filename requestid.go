@@ -0,0 +1,47 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// The header of request ID.
+const HeaderRequestID = "X-Request-Id"
+
+// The context key of request ID.
+const requestIDKey = "light.requestID"
+
+// RequestID sets the request ID for each request, it is the "X-Request-Id"
+// header of the request, or a random ID if the header is empty.
+// The ID is also set to the response header.
+func RequestID() HandlerFunc {
+	return func(c *Context) {
+		id := c.Header(HeaderRequestID)
+		if id == "" {
+			id = newRequestID()
+		}
+		c.Set(requestIDKey, id)
+		c.SetHeader(HeaderRequestID, id)
+		c.Next()
+	}
+}
+
+// Get the request ID set by the RequestID middleware.
+// Return the "X-Request-Id" header, when the middleware is not used.
+func (c *Context) RequestID() string {
+	if id, ok := c.Get(requestIDKey); ok {
+		return id.(string)
+	}
+	return c.Header(HeaderRequestID)
+}
+
+// Create a random request ID of 32 hex characters.
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
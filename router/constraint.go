@@ -0,0 +1,50 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package router
+
+import (
+	"github.com/arging/utils/errors"
+	"regexp"
+	"sync"
+)
+
+// Named constraints for param matching.
+// A piece can use the constraint name instead of the regex expression,
+// such as: /home/(id:int) equals to /home/(id:^-?[0-9]+$).
+var constraints = struct {
+	sync.RWMutex
+	m map[string]*regexp.Regexp
+}{m: map[string]*regexp.Regexp{
+	"int":   regexp.MustCompile(`^-?[0-9]+$`),
+	"uint":  regexp.MustCompile(`^[0-9]+$`),
+	"alpha": regexp.MustCompile(`^[a-zA-Z]+$`),
+	"alnum": regexp.MustCompile(`^[a-zA-Z0-9]+$`),
+	"hex":   regexp.MustCompile(`^[0-9a-fA-F]+$`),
+	"slug":  regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`),
+	"uuid":  regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`),
+}}
+
+// Register the named constraint by the regex expression.
+// The routes added after the registration can use the name.
+func RegisterConstraint(name string, expr string) errors.Error {
+	regex, err := regexp.Compile(expr)
+	if err != nil {
+		return errors.Newf("bad constraint: %s, regex expression compile error", name)
+	}
+
+	constraints.Lock()
+	constraints.m[name] = regex
+	constraints.Unlock()
+	return nil
+}
+
+// Get the regex of the named constraint.
+// Return false, when the constraint doesn't exist.
+func Constraint(name string) (*regexp.Regexp, bool) {
+	constraints.RLock()
+	regex, ok := constraints.m[name]
+	constraints.RUnlock()
+	return regex, ok
+}
@@ -0,0 +1,49 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package router
+
+import (
+	"testing"
+)
+
+func TestConstraint(t *testing.T) {
+	r1, ok1 := Constraint("int")
+	assertTrue(ok1 && r1.MatchString("-12") && !r1.MatchString("1a"), "case1", t)
+
+	_, ok2 := Constraint("none")
+	assertFalse(ok2, "case2", t)
+
+	err3 := RegisterConstraint("year", `^[0-9]{4}$`)
+	r3, ok3 := Constraint("year")
+	assertTrue(err3 == nil && ok3 && r3.MatchString("2014"), "case3", t)
+
+	err4 := RegisterConstraint("bad", `^[0-9$`)
+	assertTrue(err4 != nil, "case4", t)
+}
+
+func TestConstraintPiece(t *testing.T) {
+	p1, _ := initPiece("(id:uint)")
+	assertTrue(p1.prio == fregexM && p1.name == "id", "case1", t)
+	assertTrue(p1.match("123") && !p1.match("abc"), "case1", t)
+
+	p2, _ := initPiece("page(num : int)")
+	assertTrue(p2.prio == pregexM && p2.prefix == "page", "case2", t)
+	assertTrue(p2.match("page-1") && !p2.match("pagex"), "case2", t)
+
+	// not a constraint name, still a regex
+	p3, _ := initPiece("(id:^in.$)")
+	assertTrue(p3.match("int") && p3.match("inx"), "case3", t)
+
+	router := New("myRouter")
+	router.Add([]string{"GET"}, "/article/(id:uint)")
+	router.Add([]string{"GET"}, "/article/(slug:slug)")
+	router.Start()
+
+	result4 := router.Route("GET", "/article/42")
+	assertTrue(result4.Url == "/article/(id:uint)", "case4", t)
+	result5 := router.Route("GET", "/article/hello-world")
+	assertTrue(result5.Url == "/article/(slug:slug)", "case5", t)
+	assertTrue(result5.Params["slug"][0] == "hello-world", "case5", t)
+}
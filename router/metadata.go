@@ -0,0 +1,64 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package router
+
+// Metadata is the annotations of a route, such as the owner team, the rate
+// limit class or deprecated. It is attached when the route is added, and
+// returned in the Result of the matched route:
+//
+//	router.Add([]string{"GET"}, "/v1/users", router.Metadata{"owner": "users", "deprecated": true})
+//	result := router.Route("GET", "/v1/users")
+//	if result.Meta.Bool("deprecated") {
+//		...
+//	}
+//
+// The values can be of any type, the typed getters return the zero value
+// when the key is missing or the value is of another type.
+type Metadata map[string]interface{}
+
+// Get the value by key. Return false, when the key is not set.
+func (m Metadata) Get(key string) (interface{}, bool) {
+	value, ok := m[key]
+	return value, ok
+}
+
+// Get the string value by key.
+func (m Metadata) String(key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+// Get the bool value by key.
+func (m Metadata) Bool(key string) bool {
+	b, _ := m[key].(bool)
+	return b
+}
+
+// Get the int value by key.
+func (m Metadata) Int(key string) int {
+	i, _ := m[key].(int)
+	return i
+}
+
+// Get the strings value by key.
+func (m Metadata) Strings(key string) []string {
+	strs, _ := m[key].([]string)
+	return strs
+}
+
+// Merge the metadata into a new one, the later overrides the former.
+// Return nil, when all metadata are empty.
+func mergeMetadata(metas []Metadata) Metadata {
+	var merged Metadata
+	for _, meta := range metas {
+		for k, v := range meta {
+			if merged == nil {
+				merged = make(Metadata)
+			}
+			merged[k] = v
+		}
+	}
+	return merged
+}
@@ -0,0 +1,34 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package router
+
+import (
+	"testing"
+)
+
+func TestMetadata(t *testing.T) {
+	router := New("myRouter")
+	router.Add([]string{"GET"}, "/v1/users", Metadata{"owner": "users", "deprecated": true},
+		Metadata{"limit": 10, "owner": "accounts"})
+	router.Add([]string{"POST"}, "/v1/users", Metadata{"scopes": []string{"users:write"}})
+	router.Add([]string{"GET"}, "/v2/users")
+	if err := router.Start(); err != nil {
+		t.FailNow()
+	}
+
+	meta := router.Route("GET", "/v1/users").Meta
+	assertTrue(meta.String("owner") == "accounts" && meta.Bool("deprecated"), "case1", t)
+	assertTrue(meta.Int("limit") == 10 && meta.String("limit") == "", "case1", t)
+	_, ok := meta.Get("none")
+	assertFalse(ok, "case1", t)
+
+	meta = router.Route("POST", "/v1/users").Meta
+	assertTrue(len(meta.Strings("scopes")) == 1 && !meta.Bool("deprecated"), "case2", t)
+
+	assertTrue(router.Route("GET", "/v2/users").Meta == nil, "case3", t)
+	assertTrue(router.Route("GET", "/v3/users").Meta == nil, "case3", t)
+	var none Metadata
+	assertFalse(none.Bool("deprecated") || none.String("owner") != "", "case3", t)
+}
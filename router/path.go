@@ -6,6 +6,8 @@ package router
 
 import (
 	"github.com/arging/utils/errors"
+	"net/url"
+	"strings"
 )
 
 // Path is representation for url .
@@ -15,6 +17,7 @@ type path struct {
 	pieces []*piece // pieces in order
 	parse  bool     // if need parse params
 	origin string   // the origin url
+	meta   Metadata // the metadata of the route
 }
 
 func initPath(url string) (*path, errors.Error) {
@@ -37,7 +40,7 @@ func initPath(url string) (*path, errors.Error) {
 			break
 		}
 	}
-	return &path{len(pieces), pieces, isParse, url}, nil
+	return &path{depth: len(pieces), pieces: pieces, parse: isParse, origin: url}, nil
 }
 
 func (p *path) parseParams(strs []string) (params map[string][]string) {
@@ -85,3 +88,36 @@ func (p *path) compare(other *path) int {
 	}
 	return _EQUAL
 }
+
+// Build the url by the params, it is the reverse of parseParams.
+// The values of the same param name are used in order.
+func (p *path) build(params map[string][]string) (string, errors.Error) {
+	used := make(map[string]int)
+	strs := make([]string, p.depth)
+
+	for i, piece := range p.pieces {
+		if piece.prio == preciseM {
+			strs[i] = piece.name
+			continue
+		}
+
+		values := params[piece.name]
+		n := used[piece.name]
+		if piece.name == "" || n >= len(values) {
+			return "", errors.Newf("build url error, path: %s, missing param: %s.", p.origin, piece.name)
+		}
+		used[piece.name] = n + 1
+
+		str := piece.prefix + url.PathEscape(values[n]) + piece.suffix
+		if !piece.match(str) {
+			return "", errors.Newf("build url error, path: %s, bad param: %s=%s.", p.origin, piece.name, values[n])
+		}
+		strs[i] = str
+	}
+
+	built := pathSep + strings.Join(strs, pathSep)
+	if p.depth > 0 && strings.HasSuffix(strings.TrimSpace(p.origin), pathSep) {
+		built += pathSep
+	}
+	return built, nil
+}
@@ -108,3 +108,29 @@ func TestPathParseParams(t *testing.T) {
 	v51 := map5["id"]
 	assertTrue(len(v51) == 2 && v51[0] == "tony" && v51[1] == "123", "case p4", t)
 }
+
+func TestPathBuild(t *testing.T) {
+	p0, _ := initPath(`/`)
+	u0, err0 := p0.build(nil)
+	assertTrue(err0 == nil && u0 == "/", "case p0", t)
+
+	p1, _ := initPath(`/home/`)
+	u1, _ := p1.build(nil)
+	assertTrue(u1 == "/home/", "case p1", t)
+
+	p2, _ := initPath(`/(id)/page(num:^[0-9]+$)/view`)
+	u2, err2 := p2.build(map[string][]string{"id": {"a b"}, "num": {"12"}})
+	assertTrue(err2 == nil && u2 == "/a%20b/page12/view", "case p2", t)
+
+	p3, _ := initPath(`/(id)/page(id)`)
+	u3, _ := p3.build(map[string][]string{"id": {"tony", "123"}})
+	assertTrue(u3 == "/tony/page123", "case p3", t)
+
+	// exceptional case
+	_, err4 := p2.build(map[string][]string{"id": {"1"}})
+	assertTrue(err4 != nil, "case p4", t)
+	_, err5 := p2.build(map[string][]string{"id": {"1"}, "num": {"x"}})
+	assertTrue(err5 != nil, "case p5", t)
+	_, err6 := p3.build(map[string][]string{"id": {"tony"}})
+	assertTrue(err6 != nil, "case p6", t)
+}
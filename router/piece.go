@@ -58,9 +58,12 @@ func initPiece(str string) (*piece, errors.Error) {
 		name := strings.TrimSpace(content[:regexSepIndex])
 		expr := strings.TrimSpace(content[regexSepIndex+1:])
 
-		regex, err := regexp.Compile(expr)
-		if err != nil {
-			return nil, errors.Newf(`bad url piece: %s, regex expression compile error`, str)
+		regex, ok := Constraint(expr)
+		if !ok {
+			var err error
+			if regex, err = regexp.Compile(expr); err != nil {
+				return nil, errors.Newf(`bad url piece: %s, regex expression compile error`, str)
+			}
 		}
 
 		p.name = name
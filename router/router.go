@@ -8,7 +8,7 @@ package router
 
 import (
 	"github.com/arging/utils/errors"
-	_ "sort"
+	"sort"
 )
 
 var _ Router = &restRouter{}
@@ -20,14 +20,20 @@ type Router interface {
 	// Get the Router name
 	Name() string
 
-	// Add route url by specified methods.
-	Add(methods []string, url string)
+	// Add route url by specified methods, the metadata is returned in the
+	// Result when the route is matched. The metadata is merged at Start,
+	// the later overrides the former.
+	Add(methods []string, url string, meta ...Metadata)
 
 	// Start the router.
 	Start() errors.Error
 
 	// Route for the corresponding method and url,and resolve the params.
 	Route(method string, url string) *Result
+
+	// Get the methods which can route the url, in sorted order.
+	// The routes added without methods are not counted.
+	Methods(url string) []string
 }
 
 // The routing result.
@@ -35,10 +41,13 @@ type Router interface {
 // Otherwise,"IsMatch" will be true, and the "Url" string is the matched predefined path.
 // Even the "IsMatch" equals true, "Params" can be nil(the path doesn't need to be resloved).
 // So before use the params result, check whether params is nil first.
+// "Meta" is the metadata added with the matched path, it can be nil too.
+// It is shared by all results of the path, so don't modify it.
 type Result struct {
 	IsMatch bool
 	Url     string
 	Params  map[string][]string
+	Meta    Metadata
 }
 
 // Create a router by name.
@@ -46,10 +55,22 @@ func New(name string) Router {
 	return &restRouter{name: name}
 }
 
+// Build the url of the predefined path by the params.
+// Such as the url "/home/page(num)" and the params map[num:[1]], the built url
+// is "/home/page1". The values of the same param name are used in order.
+func BuildUrl(url string, params map[string][]string) (string, errors.Error) {
+	p, err := initPath(url)
+	if err != nil {
+		return "", errors.Wrapf(err, "build url error: %s.", url)
+	}
+	return p.build(params)
+}
+
 // Defined for origin url path.
 type routeUrl struct {
 	methods []string
 	url     string
+	meta    []Metadata
 }
 
 // Restful style struct for for Router interface
@@ -63,8 +84,8 @@ func (router *restRouter) Name() string {
 	return router.name
 }
 
-func (router *restRouter) Add(methods []string, url string) {
-	router.routeUrls = append(router.routeUrls, routeUrl{methods, url})
+func (router *restRouter) Add(methods []string, url string, meta ...Metadata) {
+	router.routeUrls = append(router.routeUrls, routeUrl{methods, url, meta})
 }
 
 func (router *restRouter) Start() errors.Error {
@@ -74,6 +95,7 @@ func (router *restRouter) Start() errors.Error {
 		if err != nil {
 			return errors.Wrapf(err, "restRouter url error: %s.", routeUrl.url)
 		}
+		p.meta = mergeMetadata(routeUrl.meta)
 
 		methods := routeUrl.methods
 		if len(methods) == 0 {
@@ -131,5 +153,16 @@ func (router *restRouter) Route(method string, url string) *Result {
 		return &Result{}
 	}
 
-	return &Result{true, target.origin, target.parseParams(strs)}
+	return &Result{true, target.origin, target.parseParams(strs), target.meta}
+}
+
+func (router *restRouter) Methods(url string) []string {
+	var methods []string
+	for method := range router.urlMapping {
+		if method != "" && router.Route(method, url).IsMatch {
+			methods = append(methods, method)
+		}
+	}
+	sort.Strings(methods)
+	return methods
 }
@@ -6,6 +6,7 @@ package router
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -109,3 +110,23 @@ func TestRouterRoute(t *testing.T) {
 	assertTrue(result16.Url == `/home/profile1/view`, "case16", t)
 	assertTrue(result16.Params == nil, "case16", t)
 }
+
+func TestRouterMethods(t *testing.T) {
+	router := New("myRouter")
+	router.Add([]string{"GET", "POST"}, "/users")
+	router.Add([]string{"PUT", "DELETE"}, "/users/(id)")
+	router.Add([]string{"GET"}, "/users/new")
+	router.Add(nil, "/any")
+
+	err := router.Start()
+	if err != nil {
+		t.FailNow()
+	}
+
+	assertTrue(strings.Join(router.Methods("/users"), ",") == "GET,POST", "case1", t)
+	// the shorter path "/users" also matches
+	assertTrue(strings.Join(router.Methods("/users/1"), ",") == "DELETE,GET,POST,PUT", "case2", t)
+	assertTrue(strings.Join(router.Methods("/users/new"), ",") == "DELETE,GET,POST,PUT", "case3", t)
+	assertTrue(router.Methods("/any") == nil, "case4", t)
+	assertTrue(router.Methods("/none") == nil, "case4", t)
+}
@@ -0,0 +1,229 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+// Package securecookie encodes the cookie values, so they can't be forged.
+//
+// The value is stamped with the encoding time and signed by HMAC-SHA256 with
+// the hash key, and it is also encrypted by AES-GCM if the block key is set:
+//
+//	codec, err := securecookie.New(hashKey, blockKey)
+//	encoded, err := codec.Encode("session", value)
+//	value, err := codec.Decode("session", encoded)
+//
+// The cookie name is signed with the value, so a value can't be moved to
+// another cookie. The value older than the MaxAge is rejected.
+//
+// The keys can be rotated by Codecs, the new keys encode and all the keys
+// decode, so the clients don't lose their cookies:
+//
+//	codecs, err := securecookie.CodecsFromPairs(newHash, newBlock, oldHash, oldBlock)
+package securecookie
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"github.com/arging/utils/errors"
+	"time"
+)
+
+// The min length of hash key.
+const minHashKeyLen = 32
+
+// The length of timestamp in the payload.
+const timestampLen = 8
+
+// The allowed clock skew for the timestamp from the future.
+const maxClockSkew = time.Minute
+
+// The default max age of the encoded values.
+const DefaultMaxAge = 30 * 24 * time.Hour
+
+// Codec signs and encrypts the cookie values.
+// It is safe for concurrent use, after the MaxAge is set.
+type Codec struct {
+	// The values older than the max age are rejected, zero is no limit.
+	// Default is DefaultMaxAge.
+	MaxAge time.Duration
+
+	hashKey []byte
+	aead    cipher.AEAD // nil if the value isn't encrypted
+	now     func() time.Time
+}
+
+// Create the codec by the keys. The hash key signs the values, it should be
+// 32 or 64 random bytes. The block key encrypts the values, it is 16, 24 or
+// 32 bytes for AES-128, AES-192 or AES-256. If it is nil, the values are
+// only signed.
+func New(hashKey []byte, blockKey []byte) (*Codec, errors.Error) {
+	if len(hashKey) < minHashKeyLen {
+		return nil, errors.Newf("securecookie error, hash key is shorter than %d bytes.", minHashKeyLen)
+	}
+	c := &Codec{MaxAge: DefaultMaxAge, hashKey: hashKey, now: time.Now}
+	if blockKey != nil {
+		block, err := aes.NewCipher(blockKey)
+		if err != nil {
+			return nil, errors.Wrapf(err, "securecookie error, invalid block key.")
+		}
+		if c.aead, err = cipher.NewGCM(block); err != nil {
+			return nil, errors.Wrapf(err, "securecookie error, invalid block key.")
+		}
+	}
+	return c, nil
+}
+
+// Encode the value of the named cookie with current time.
+// The result is URL-safe base64, it can be the cookie value directly.
+func (c *Codec) Encode(name string, value []byte) (string, errors.Error) {
+	payload := make([]byte, timestampLen, timestampLen+len(value))
+	binary.BigEndian.PutUint64(payload, uint64(c.now().Unix()))
+	payload = append(payload, value...)
+
+	if c.aead != nil {
+		nonce := make([]byte, c.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", errors.Wrapf(err, "securecookie encode error: %s.", name)
+		}
+		payload = c.aead.Seal(nonce, nonce, payload, []byte(name))
+	}
+
+	data := make([]byte, 0, len(payload)+sha256.Size)
+	data = append(append(data, payload...), c.mac(name, payload)...)
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// Decode the value of the named cookie.
+// It fails, if the value is forged, expired, or encoded by other keys.
+func (c *Codec) Decode(name string, encoded string) ([]byte, errors.Error) {
+	value, _, err := c.DecodeTime(name, encoded)
+	return value, err
+}
+
+// Decode the value of the named cookie, and get the time when it is encoded.
+// It fails, if the value is forged, expired, or encoded by other keys.
+func (c *Codec) DecodeTime(name string, encoded string) ([]byte, time.Time, errors.Error) {
+	var zero time.Time
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, zero, errors.Wrapf(err, "securecookie decode error, invalid base64: %s.", name)
+	}
+	if len(data) < sha256.Size {
+		return nil, zero, errors.Newf("securecookie decode error, too short: %s.", name)
+	}
+
+	payload, mac := data[:len(data)-sha256.Size], data[len(data)-sha256.Size:]
+	if !hmac.Equal(mac, c.mac(name, payload)) {
+		return nil, zero, errors.Newf("securecookie decode error, invalid mac: %s.", name)
+	}
+	if c.aead != nil {
+		size := c.aead.NonceSize()
+		if len(payload) < size {
+			return nil, zero, errors.Newf("securecookie decode error, too short: %s.", name)
+		}
+		if payload, err = c.aead.Open(nil, payload[:size], payload[size:], []byte(name)); err != nil {
+			return nil, zero, errors.Wrapf(err, "securecookie decode error, decrypt failed: %s.", name)
+		}
+	}
+	if len(payload) < timestampLen {
+		return nil, zero, errors.Newf("securecookie decode error, no timestamp: %s.", name)
+	}
+
+	t := time.Unix(int64(binary.BigEndian.Uint64(payload)), 0)
+	now := c.now()
+	if t.After(now.Add(maxClockSkew)) {
+		return nil, zero, errors.Newf("securecookie decode error, timestamp from future: %s.", name)
+	}
+	if c.MaxAge > 0 && now.Sub(t) > c.MaxAge {
+		return nil, zero, errors.Newf("securecookie decode error, expired: %s.", name)
+	}
+	return payload[timestampLen:], t, nil
+}
+
+// Get the HMAC of the cookie name and payload.
+func (c *Codec) mac(name string, payload []byte) []byte {
+	h := hmac.New(sha256.New, c.hashKey)
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write(payload)
+	return h.Sum(nil)
+}
+
+// Codecs rotates the keys. The first codec encodes the values, and all
+// codecs are tried in order to decode them.
+type Codecs []*Codec
+
+// Create the codecs by the pairs of hash key and block key, the newest pair
+// is the first. The block key can be nil, and the last one can be omitted.
+func CodecsFromPairs(keyPairs ...[]byte) (Codecs, errors.Error) {
+	if len(keyPairs) == 0 {
+		return nil, errors.New("securecookie error, no keys.")
+	}
+	codecs := make(Codecs, 0, (len(keyPairs)+1)/2)
+	for i := 0; i < len(keyPairs); i += 2 {
+		var blockKey []byte
+		if i+1 < len(keyPairs) {
+			blockKey = keyPairs[i+1]
+		}
+		codec, err := New(keyPairs[i], blockKey)
+		if err != nil {
+			return nil, errors.Wrapf(err, "securecookie error, key pair %d.", i/2)
+		}
+		codecs = append(codecs, codec)
+	}
+	return codecs, nil
+}
+
+// Set the max age of all codecs.
+func (cs Codecs) SetMaxAge(maxAge time.Duration) {
+	for _, c := range cs {
+		c.MaxAge = maxAge
+	}
+}
+
+// Encode the value of the named cookie by the first codec.
+func (cs Codecs) Encode(name string, value []byte) (string, errors.Error) {
+	if len(cs) == 0 {
+		return "", errors.Newf("securecookie encode error, no codecs: %s.", name)
+	}
+	return cs[0].Encode(name, value)
+}
+
+// Decode the value of the named cookie by the codecs in order.
+// Return the error of the first codec, when all codecs fail.
+func (cs Codecs) Decode(name string, encoded string) ([]byte, errors.Error) {
+	value, _, err := cs.DecodeTime(name, encoded)
+	return value, err
+}
+
+// Decode the value of the named cookie by the codecs in order, and get the
+// time when it is encoded.
+func (cs Codecs) DecodeTime(name string, encoded string) ([]byte, time.Time, errors.Error) {
+	var first errors.Error
+	for _, c := range cs {
+		value, t, err := c.DecodeTime(name, encoded)
+		if err == nil {
+			return value, t, nil
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	if first == nil {
+		first = errors.Newf("securecookie decode error, no codecs: %s.", name)
+	}
+	return nil, time.Time{}, first
+}
+
+// Generate a random key of the length, such as 32 for the hash key.
+func GenerateKey(length int) []byte {
+	key := make([]byte, length)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return key
+}
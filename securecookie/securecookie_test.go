@@ -0,0 +1,128 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package securecookie
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func assertTrue(b bool, msg string, t *testing.T) {
+	if !b {
+		t.Error(msg)
+	}
+}
+
+func TestCodec(t *testing.T) {
+	hashKey := GenerateKey(32)
+	for i, blockKey := range [][]byte{nil, GenerateKey(16), GenerateKey(32)} {
+		codec, err := New(hashKey, blockKey)
+		assertTrue(err == nil, "case1", t)
+
+		encoded, err := codec.Encode("session", []byte("user=1"))
+		assertTrue(err == nil && !strings.ContainsAny(encoded, "+/=;, "), "case1", t)
+		value, err := codec.Decode("session", encoded)
+		assertTrue(err == nil && string(value) == "user=1", "case1", t)
+		// encrypted value is hidden
+		if blockKey != nil {
+			assertTrue(!strings.Contains(encoded, "dXNlcj0x"), "case2", t)
+		}
+
+		// the name is signed
+		_, err = codec.Decode("other", encoded)
+		assertTrue(err != nil, "case3", t)
+
+		// forged
+		forged := []byte(encoded)
+		forged[len(forged)/2] ^= 1
+		_, err = codec.Decode("session", string(forged))
+		assertTrue(err != nil, "case4", t)
+		_, err = codec.Decode("session", "!!!")
+		assertTrue(err != nil, "case4", t)
+		_, err = codec.Decode("session", "abc")
+		assertTrue(err != nil, "case4", t)
+
+		// other keys
+		other, _ := New(GenerateKey(32), blockKey)
+		_, err = other.Decode("session", encoded)
+		if err == nil {
+			t.Errorf("case5 %d", i)
+		}
+	}
+}
+
+func TestCodecKeys(t *testing.T) {
+	_, err := New(GenerateKey(16), nil)
+	assertTrue(err != nil, "case1", t)
+	_, err = New(GenerateKey(32), GenerateKey(10))
+	assertTrue(err != nil, "case2", t)
+
+	// the value isn't changed by encoding
+	codec, _ := New(GenerateKey(64), nil)
+	value := make([]byte, 3, 100)
+	copy(value, "abc")
+	codec.Encode("a", value)
+	assertTrue(bytes.Equal(value[:cap(value)][3:35], make([]byte, 32)), "case3", t)
+}
+
+func TestCodecMaxAge(t *testing.T) {
+	codec, _ := New(GenerateKey(32), GenerateKey(16))
+	now := time.Now()
+	codec.now = func() time.Time { return now }
+	encoded, _ := codec.Encode("a", []byte("v"))
+
+	value, ts, err := codec.DecodeTime("a", encoded)
+	assertTrue(err == nil && string(value) == "v" && ts.Unix() == now.Unix(), "case1", t)
+
+	// expired
+	codec.now = func() time.Time { return now.Add(DefaultMaxAge + time.Second) }
+	_, err = codec.Decode("a", encoded)
+	assertTrue(err != nil && strings.Contains(err.Error(), "expired"), "case2", t)
+	codec.MaxAge = 0
+	_, err = codec.Decode("a", encoded)
+	assertTrue(err == nil, "case2", t)
+
+	// from future
+	codec.now = func() time.Time { return now.Add(-2 * time.Minute) }
+	_, err = codec.Decode("a", encoded)
+	assertTrue(err != nil && strings.Contains(err.Error(), "future"), "case3", t)
+}
+
+func TestCodecs(t *testing.T) {
+	oldHash, oldBlock := GenerateKey(32), GenerateKey(32)
+	old, err := CodecsFromPairs(oldHash, oldBlock)
+	assertTrue(err == nil && len(old) == 1, "case1", t)
+	encoded, _ := old.Encode("a", []byte("v"))
+
+	// rotated, the old value is still decoded
+	codecs, err := CodecsFromPairs(GenerateKey(32), GenerateKey(32), oldHash, oldBlock)
+	assertTrue(err == nil && len(codecs) == 2, "case2", t)
+	value, err := codecs.Decode("a", encoded)
+	assertTrue(err == nil && string(value) == "v", "case2", t)
+
+	// the new key encodes
+	encoded, _ = codecs.Encode("a", []byte("w"))
+	_, err = old.Decode("a", encoded)
+	assertTrue(err != nil, "case3", t)
+	value, _ = codecs[:1].Decode("a", encoded)
+	assertTrue(string(value) == "w", "case3", t)
+
+	// the last block key can be omitted
+	codecs, err = CodecsFromPairs(GenerateKey(32), nil, GenerateKey(32))
+	assertTrue(err == nil && len(codecs) == 2 && codecs[1].aead == nil, "case4", t)
+	codecs.SetMaxAge(time.Hour)
+	assertTrue(codecs[0].MaxAge == time.Hour && codecs[1].MaxAge == time.Hour, "case4", t)
+
+	_, err = CodecsFromPairs()
+	assertTrue(err != nil, "case5", t)
+	_, err = CodecsFromPairs(GenerateKey(32), nil, GenerateKey(8))
+	assertTrue(err != nil, "case5", t)
+	_, err = Codecs{}.Encode("a", nil)
+	assertTrue(err != nil, "case5", t)
+	_, err = Codecs{}.Decode("a", encoded)
+	assertTrue(err != nil, "case5", t)
+}
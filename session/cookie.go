@@ -0,0 +1,90 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package session
+
+import (
+	"bytes"
+	"encoding/gob"
+	"github.com/arging/utils/errors"
+	"github.com/uestcer/light/securecookie"
+	"net/http"
+	"time"
+)
+
+var _ Store = &CookieStore{}
+
+// The max size of a cookie accepted by browsers.
+const maxCookieSize = 4096
+
+// CookieStore keeps the session data in the cookie. The data is signed, and
+// it is also encrypted if the block key is set, see securecookie.New.
+// The data should be small, since the cookie is limited to 4KB.
+type CookieStore struct {
+	Options Options
+
+	// The codecs of the cookie, the max age of them can be changed.
+	Codecs securecookie.Codecs
+}
+
+// Defined for the session data in the cookie.
+type cookieData struct {
+	ID         string
+	Values     map[string]interface{}
+	CreatedAt  time.Time
+	AccessedAt time.Time
+}
+
+// Create the cookie store by the pairs of hash key and block key, the newest
+// pair is the first, so the keys can be rotated. See securecookie.CodecsFromPairs.
+func NewCookieStore(keyPairs ...[]byte) (*CookieStore, errors.Error) {
+	codecs, err := securecookie.CodecsFromPairs(keyPairs...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cookie store error.")
+	}
+	return &CookieStore{Options: DefaultOptions, Codecs: codecs}, nil
+}
+
+func (s *CookieStore) Load(r *http.Request, name string) (*Session, error) {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return New(name), nil
+	}
+	value, err := s.Codecs.Decode(name, cookie.Value)
+	if err != nil {
+		return New(name), nil
+	}
+
+	var data cookieData
+	if err := gob.NewDecoder(bytes.NewReader(value)).Decode(&data); err != nil {
+		return New(name), nil
+	}
+	return Loaded(name, data.ID, data.Values, data.CreatedAt, data.AccessedAt), nil
+}
+
+func (s *CookieStore) Save(w http.ResponseWriter, r *http.Request, sess *Session) error {
+	if sess.IsDestroyed() {
+		http.SetCookie(w, s.Options.Cookie(sess.Name(), ""))
+		sess.Saved()
+		return nil
+	}
+
+	var buf bytes.Buffer
+	data := &cookieData{sess.ID, sess.Values, sess.CreatedAt, sess.AccessedAt}
+	if err := gob.NewEncoder(&buf).Encode(data); err != nil {
+		return errors.Wrapf(err, "cookie store encode error: %s.", sess.Name())
+	}
+	value, err := s.Codecs.Encode(sess.Name(), buf.Bytes())
+	if err != nil {
+		return errors.Wrapf(err, "cookie store encode error: %s.", sess.Name())
+	}
+
+	cookie := s.Options.Cookie(sess.Name(), value)
+	if len(cookie.String()) > maxCookieSize {
+		return errors.Newf("cookie store error, the cookie is larger than %d bytes: %s.", maxCookieSize, sess.Name())
+	}
+	http.SetCookie(w, cookie)
+	sess.Saved()
+	return nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package session
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+var _ Store = &MemoryStore{}
+
+// MemoryStore keeps the sessions in memory, the cookie is the session ID.
+// The session expires after the TTL since it is saved last time, and the
+// expired sessions are removed by Sweep. The sessions are lost when the
+// process exits, and they are not shared by multiple processes.
+type MemoryStore struct {
+	Options Options
+
+	ttl      time.Duration
+	mu       sync.Mutex
+	sessions map[string]*memoryEntry
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// Defined for a session in memory.
+type memoryEntry struct {
+	values   map[string]interface{}
+	created  time.Time
+	accessed time.Time
+	expires  time.Time // zero if never expires
+}
+
+// Create the memory store by the TTL, zero TTL never expires.
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{
+		Options:  DefaultOptions,
+		ttl:      ttl,
+		sessions: make(map[string]*memoryEntry),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Sweep the expired sessions in the background by the interval, until the
+// store is closed.
+func (s *MemoryStore) StartSweeper(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.Sweep()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop the sweeper.
+func (s *MemoryStore) Close() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+}
+
+// Remove the expired sessions, and return the removed count.
+func (s *MemoryStore) Sweep() int {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for id, entry := range s.sessions {
+		if entry.expired(now) {
+			delete(s.sessions, id)
+			n++
+		}
+	}
+	return n
+}
+
+// Get the count of sessions, including the expired ones not swept.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sessions)
+}
+
+func (s *MemoryStore) Load(r *http.Request, name string) (*Session, error) {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return New(name), nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.sessions[cookie.Value]
+	if !ok {
+		return New(name), nil
+	}
+	if entry.expired(time.Now()) {
+		delete(s.sessions, cookie.Value)
+		return New(name), nil
+	}
+	return Loaded(name, cookie.Value, copyValues(entry.values), entry.created, entry.accessed), nil
+}
+
+func (s *MemoryStore) Save(w http.ResponseWriter, r *http.Request, sess *Session) error {
+	s.mu.Lock()
+	if old := sess.OldID(); old != "" {
+		delete(s.sessions, old)
+	}
+	if sess.IsDestroyed() {
+		delete(s.sessions, sess.ID)
+		s.mu.Unlock()
+		http.SetCookie(w, s.Options.Cookie(sess.Name(), ""))
+		sess.Saved()
+		return nil
+	}
+
+	entry := &memoryEntry{
+		values:   copyValues(sess.Values),
+		created:  sess.CreatedAt,
+		accessed: sess.AccessedAt,
+	}
+	if s.ttl > 0 {
+		entry.expires = time.Now().Add(s.ttl)
+	}
+	s.sessions[sess.ID] = entry
+	s.mu.Unlock()
+
+	http.SetCookie(w, s.Options.Cookie(sess.Name(), sess.ID))
+	sess.Saved()
+	return nil
+}
+
+// Is the session expired at the time.
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && now.After(e.expires)
+}
+
+// Copy the values, so the requests don't share the map.
+func copyValues(values map[string]interface{}) map[string]interface{} {
+	m := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		m[k] = v
+	}
+	return m
+}
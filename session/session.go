@@ -0,0 +1,206 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+// Package session is the server-side session module for light framework.
+//
+// A session is loaded from a Store by the request cookie, and saved to the
+// Store before the response is written. Two stores are provided:
+//
+//	CookieStore  the session data is signed and encrypted in the cookie
+//	MemoryStore  the session data is in memory, the cookie is the session ID
+//
+// Implement the Store interface for other backends, such as a database.
+package session
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"time"
+)
+
+// Store loads and saves the sessions.
+type Store interface {
+
+	// Load the session of the named cookie from the request.
+	// A new session is returned, if the cookie is missing, invalid or
+	// expired in the store. The error is only for the store failure.
+	Load(r *http.Request, name string) (*Session, error)
+
+	// Save the session, and set the cookie to the response.
+	// If the session is destroyed, it is deleted and the cookie is removed.
+	// If the session ID is regenerated, the old session is deleted.
+	Save(w http.ResponseWriter, r *http.Request, s *Session) error
+}
+
+// Options is the attributes of the session cookie.
+type Options struct {
+	Path     string        // default is "/"
+	Domain   string        // default is the host of the request
+	MaxAge   time.Duration // 0 is the browser session cookie
+	Secure   bool
+	HttpOnly bool
+	SameSite http.SameSite
+}
+
+// The default cookie options, the cookie is hidden from scripts.
+var DefaultOptions = Options{Path: "/", HttpOnly: true, SameSite: http.SameSiteLaxMode}
+
+// Create the cookie of the session by the options.
+// If the value is empty, the cookie is removed.
+func (o *Options) Cookie(name string, value string) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     o.Path,
+		Domain:   o.Domain,
+		Secure:   o.Secure,
+		HttpOnly: o.HttpOnly,
+		SameSite: o.SameSite,
+	}
+	if cookie.Path == "" {
+		cookie.Path = "/"
+	}
+	if value == "" {
+		cookie.MaxAge = -1
+		cookie.Expires = time.Unix(1, 0)
+	} else if o.MaxAge > 0 {
+		cookie.MaxAge = int(o.MaxAge / time.Second)
+		cookie.Expires = time.Now().Add(o.MaxAge)
+	}
+	return cookie
+}
+
+// Session is the data of a client across requests.
+// The values should be encodable by encoding/gob for CookieStore, the custom
+// types should be registered by gob.Register.
+type Session struct {
+	ID         string                 // The random session ID
+	Values     map[string]interface{} // The session values
+	CreatedAt  time.Time              // The time when the session is created
+	AccessedAt time.Time              // The last time when the session is saved
+
+	name      string
+	isNew     bool
+	modified  bool
+	destroyed bool
+	oldID     string // The ID before regenerated, it is deleted by the store
+}
+
+// Create a new session of the cookie name.
+func New(name string) *Session {
+	now := time.Now()
+	return &Session{
+		ID:         NewID(),
+		Values:     make(map[string]interface{}),
+		CreatedAt:  now,
+		AccessedAt: now,
+		name:       name,
+		isNew:      true,
+	}
+}
+
+// Create a loaded session of the cookie name, it is used by the stores.
+func Loaded(name string, id string, values map[string]interface{}, created time.Time, accessed time.Time) *Session {
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return &Session{ID: id, Values: values, CreatedAt: created, AccessedAt: accessed, name: name}
+}
+
+// Create a random session ID, it is 32 random bytes in URL-safe base64.
+func NewID() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Get the cookie name of the session.
+func (s *Session) Name() string {
+	return s.name
+}
+
+// Get the value by key.
+func (s *Session) Get(key string) (interface{}, bool) {
+	value, ok := s.Values[key]
+	return value, ok
+}
+
+// Set the value by key.
+func (s *Session) Set(key string, value interface{}) {
+	s.Values[key] = value
+	s.modified = true
+}
+
+// Delete the value by key.
+func (s *Session) Delete(key string) {
+	if _, ok := s.Values[key]; ok {
+		delete(s.Values, key)
+		s.modified = true
+	}
+}
+
+// Delete all values.
+func (s *Session) Clear() {
+	if len(s.Values) > 0 {
+		s.Values = make(map[string]interface{})
+		s.modified = true
+	}
+}
+
+// Mark the session modified, so it is saved even no value is changed.
+// It is used to refresh the session, such as for the idle timeout.
+func (s *Session) Touch() {
+	s.modified = true
+}
+
+// Regenerate the session ID and keep the values. It should be called when
+// the privilege changes, such as login, to prevent the session fixation.
+// The creation time is also reset.
+func (s *Session) Regenerate() {
+	if s.oldID == "" && !s.isNew {
+		s.oldID = s.ID
+	}
+	s.ID = NewID()
+	s.CreatedAt = time.Now()
+	s.modified = true
+}
+
+// Destroy the session, such as logout. It is deleted from the store and the
+// cookie is removed when saved.
+func (s *Session) Destroy() {
+	s.Values = make(map[string]interface{})
+	s.destroyed = true
+	s.modified = true
+}
+
+// Is the session created in this request.
+func (s *Session) IsNew() bool {
+	return s.isNew
+}
+
+// Is the session modified, so it should be saved.
+func (s *Session) IsModified() bool {
+	return s.modified
+}
+
+// Is the session destroyed.
+func (s *Session) IsDestroyed() bool {
+	return s.destroyed
+}
+
+// Get the ID before regenerated, it should be deleted by the store.
+// Return empty string, when the ID isn't regenerated.
+func (s *Session) OldID() string {
+	return s.oldID
+}
+
+// Mark the session saved, it is called by the stores.
+func (s *Session) Saved() {
+	s.isNew = false
+	s.modified = false
+	s.oldID = ""
+}
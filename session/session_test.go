@@ -0,0 +1,134 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package session
+
+import (
+	"github.com/uestcer/light/securecookie"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func assertTrue(b bool, msg string, t *testing.T) {
+	if !b {
+		t.Error(msg)
+	}
+}
+
+// Save the session, and get the request with the response cookies.
+func roundTrip(store Store, s *Session) (*http.Request, *http.Cookie) {
+	w := httptest.NewRecorder()
+	store.Save(w, httptest.NewRequest("GET", "/", nil), s)
+	r := httptest.NewRequest("GET", "/", nil)
+	cookies := w.Result().Cookies()
+	if len(cookies) == 0 {
+		return r, nil
+	}
+	r.AddCookie(cookies[0])
+	return r, cookies[0]
+}
+
+func testStore(store Store, t *testing.T) {
+	s, err := store.Load(httptest.NewRequest("GET", "/", nil), "sid")
+	assertTrue(err == nil && s.IsNew() && s.Name() == "sid" && len(s.ID) == 43, "case1", t)
+
+	s.Set("user", "li")
+	s.Set("age", 18)
+	r, cookie := roundTrip(store, s)
+	assertTrue(!s.IsNew() && !s.IsModified(), "case2", t)
+	assertTrue(cookie.HttpOnly && cookie.Path == "/" && cookie.SameSite == http.SameSiteLaxMode, "case2", t)
+
+	loaded, _ := store.Load(r, "sid")
+	user, _ := loaded.Get("user")
+	age, _ := loaded.Get("age")
+	assertTrue(!loaded.IsNew() && loaded.ID == s.ID && user == "li" && age == 18, "case3", t)
+	assertTrue(loaded.CreatedAt.Equal(s.CreatedAt), "case3", t)
+
+	// regenerate keeps the values
+	id := loaded.ID
+	loaded.Regenerate()
+	assertTrue(loaded.OldID() == id && loaded.ID != id, "case4", t)
+	r2, _ := roundTrip(store, loaded)
+	regenerated, _ := store.Load(r2, "sid")
+	user, _ = regenerated.Get("user")
+	assertTrue(regenerated.ID != id && user == "li", "case4", t)
+
+	// destroy
+	regenerated.Destroy()
+	_, cookie = roundTrip(store, regenerated)
+	assertTrue(cookie.MaxAge < 0 && cookie.Value == "", "case5", t)
+
+	// invalid cookie
+	r = httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: "sid", Value: "invalid"})
+	s, err = store.Load(r, "sid")
+	assertTrue(err == nil && s.IsNew(), "case6", t)
+}
+
+func TestCookieStore(t *testing.T) {
+	store, err := NewCookieStore(securecookie.GenerateKey(32), securecookie.GenerateKey(32))
+	assertTrue(err == nil, "case0", t)
+	testStore(store, t)
+
+	// the cookie is too large
+	s := New("sid")
+	s.Set("data", string(securecookie.GenerateKey(4096)))
+	w := httptest.NewRecorder()
+	assertTrue(store.Save(w, httptest.NewRequest("GET", "/", nil), s) != nil, "case7", t)
+
+	_, err = NewCookieStore(nil, nil)
+	assertTrue(err != nil, "case8", t)
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+	testStore(store, t)
+	// the regenerated and destroyed sessions are deleted
+	assertTrue(store.Len() == 0, "case7", t)
+
+	store = NewMemoryStore(10 * time.Millisecond)
+	store.Options.MaxAge = time.Minute
+	s := New("sid")
+	s.Set("a", 1)
+	r, cookie := roundTrip(store, s)
+	assertTrue(cookie.Value == s.ID && cookie.MaxAge == 60, "case8", t)
+
+	// the values are copied
+	loaded, _ := store.Load(r, "sid")
+	loaded.Set("a", 2)
+	loaded, _ = store.Load(r, "sid")
+	a, _ := loaded.Get("a")
+	assertTrue(a == 1, "case9", t)
+
+	time.Sleep(20 * time.Millisecond)
+	loaded, _ = store.Load(r, "sid")
+	assertTrue(loaded.IsNew() && store.Len() == 0, "case10", t)
+
+	roundTrip(store, s)
+	roundTrip(store, New("sid"))
+	store.StartSweeper(5 * time.Millisecond)
+	defer store.Close()
+	time.Sleep(50 * time.Millisecond)
+	assertTrue(store.Len() == 0, "case11", t)
+}
+
+func TestCookieStoreRotation(t *testing.T) {
+	oldHash, oldBlock := securecookie.GenerateKey(32), securecookie.GenerateKey(32)
+	old, _ := NewCookieStore(oldHash, oldBlock)
+	s := New("sid")
+	s.Set("user", "li")
+	r, _ := roundTrip(old, s)
+
+	store, _ := NewCookieStore(securecookie.GenerateKey(32), securecookie.GenerateKey(32), oldHash, oldBlock)
+	loaded, _ := store.Load(r, "sid")
+	user, _ := loaded.Get("user")
+	assertTrue(!loaded.IsNew() && user == "li", "case1", t)
+
+	store.Codecs.SetMaxAge(0)
+	r, _ = roundTrip(store, loaded)
+	loaded, _ = old.Load(r, "sid")
+	assertTrue(loaded.IsNew(), "case2", t)
+}
@@ -0,0 +1,99 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"github.com/uestcer/light/session"
+	"net/http"
+	"time"
+)
+
+// The default cookie name of session.
+const DefaultSessionName = "light_session"
+
+// The context key of session.
+const sessionKey = "light.session"
+
+// SessionConfig is the config for Sessions middleware.
+type SessionConfig struct {
+	Store session.Store // The session store, required
+	Name  string        // The cookie name, default is "light_session"
+
+	// The session expires if it isn't accessed for the idle timeout.
+	// Zero is no idle timeout. The session is saved for each request to
+	// refresh the access time.
+	IdleTimeout time.Duration
+
+	// The session expires after the absolute timeout since it is created,
+	// even if it is active. Zero is no absolute timeout.
+	AbsoluteTimeout time.Duration
+}
+
+// Sessions loads the session from the store for each request, and saves it
+// before the response is written if it is modified:
+//
+//	store := session.NewMemoryStore(24 * time.Hour)
+//	mux.Use(light.Sessions(light.SessionConfig{Store: store, IdleTimeout: time.Hour}))
+//
+//	func login(c *light.Context) {
+//		s := c.Session()
+//		s.Regenerate() // prevent session fixation
+//		s.Set("user", id)
+//	}
+//
+// The expired session is replaced by a new empty session with a new ID.
+func Sessions(config SessionConfig) HandlerFunc {
+	if config.Name == "" {
+		config.Name = DefaultSessionName
+	}
+	return func(c *Context) {
+		s, err := config.Store.Load(c.Request, config.Name)
+		if err != nil {
+			c.Error(WrapHTTPError(err, http.StatusInternalServerError, ""))
+			return
+		}
+
+		now := time.Now()
+		if !s.IsNew() && (config.IdleTimeout > 0 && now.Sub(s.AccessedAt) > config.IdleTimeout ||
+			config.AbsoluteTimeout > 0 && now.Sub(s.CreatedAt) > config.AbsoluteTimeout) {
+			s.Clear()
+			s.Regenerate()
+		}
+		if config.IdleTimeout > 0 && !s.IsNew() {
+			s.AccessedAt = now
+			s.Touch()
+		}
+		c.Set(sessionKey, s)
+
+		saved := false
+		save := func() {
+			if saved {
+				return
+			}
+			saved = true
+			// The new empty session isn't saved, so no cookie for every client.
+			if !s.IsModified() || s.IsNew() && len(s.Values) == 0 && !s.IsDestroyed() {
+				return
+			}
+			if err := config.Store.Save(c.Response, c.Request, s); err != nil {
+				c.mux.Logger.Printf("save session error: %v", err)
+			}
+		}
+		c.BeforeWrite(save)
+		c.Next()
+		if !c.Response.Written() {
+			save()
+		}
+	}
+}
+
+// Get the session loaded by the Sessions middleware.
+// Return nil, when the middleware is not used.
+func (c *Context) Session() *session.Session {
+	if s, ok := c.Get(sessionKey); ok {
+		return s.(*session.Session)
+	}
+	return nil
+}
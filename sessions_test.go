@@ -0,0 +1,106 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"fmt"
+	"github.com/uestcer/light/session"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sessionMux(config SessionConfig) *Mux {
+	mux := NewMux("myMux")
+	mux.Use(Sessions(config))
+	mux.Add([]string{"GET"}, "/login", func(c *Context) {
+		c.Session().Regenerate()
+		c.Session().Set("user", c.Query("user"))
+		c.Text(http.StatusOK, "ok")
+	})
+	mux.Add([]string{"GET"}, "/me", func(c *Context) {
+		user, _ := c.Session().Get("user")
+		c.Text(http.StatusOK, fmt.Sprint(user))
+	})
+	mux.Add([]string{"GET"}, "/logout", func(c *Context) {
+		c.Session().Destroy()
+	})
+	mux.Start()
+	return mux
+}
+
+// Serve the request with the session cookie.
+func serveSession(mux *Mux, url string, cookie *http.Cookie) (*httptest.ResponseRecorder, *http.Cookie) {
+	r := httptest.NewRequest("GET", url, nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	for _, c := range w.Result().Cookies() {
+		if c.Name == DefaultSessionName {
+			return w, c
+		}
+	}
+	return w, nil
+}
+
+func TestSessions(t *testing.T) {
+	store := session.NewMemoryStore(time.Hour)
+	mux := sessionMux(SessionConfig{Store: store})
+
+	// no cookie for the new empty session
+	w, cookie := serveSession(mux, "/me", nil)
+	assertTrue(w.Body.String() == "<nil>" && cookie == nil, "case1", t)
+
+	// saved before the body is written
+	w, cookie = serveSession(mux, "/login?user=li", nil)
+	assertTrue(w.Body.String() == "ok" && cookie != nil, "case2", t)
+	w, _ = serveSession(mux, "/me", cookie)
+	assertTrue(w.Body.String() == "li", "case2", t)
+
+	// regenerated on login
+	_, newCookie := serveSession(mux, "/login?user=admin", cookie)
+	assertTrue(newCookie.Value != cookie.Value, "case3", t)
+	w, _ = serveSession(mux, "/me", cookie)
+	assertTrue(w.Body.String() == "<nil>", "case3", t)
+	w, _ = serveSession(mux, "/me", newCookie)
+	assertTrue(w.Body.String() == "admin", "case3", t)
+
+	// destroyed without body
+	_, removed := serveSession(mux, "/logout", newCookie)
+	assertTrue(removed.MaxAge < 0, "case4", t)
+	w, _ = serveSession(mux, "/me", newCookie)
+	assertTrue(w.Body.String() == "<nil>" && store.Len() == 0, "case4", t)
+
+	assertTrue(NewMux("myMux").pool.New().(*Context).Session() == nil, "case5", t)
+}
+
+func TestSessionsTimeout(t *testing.T) {
+	store := session.NewMemoryStore(time.Hour)
+	mux := sessionMux(SessionConfig{Store: store, IdleTimeout: 30 * time.Millisecond})
+
+	_, cookie := serveSession(mux, "/login?user=li", nil)
+	for i := 0; i < 3; i++ {
+		time.Sleep(15 * time.Millisecond)
+		w, _ := serveSession(mux, "/me", cookie)
+		assertTrue(w.Body.String() == "li", "case1", t)
+	}
+	time.Sleep(40 * time.Millisecond)
+	w, expired := serveSession(mux, "/me", cookie)
+	assertTrue(w.Body.String() == "<nil>" && expired.Value != cookie.Value, "case2", t)
+
+	mux = sessionMux(SessionConfig{Store: store, AbsoluteTimeout: 30 * time.Millisecond})
+	_, cookie = serveSession(mux, "/login?user=li", nil)
+	time.Sleep(15 * time.Millisecond)
+	w, _ = serveSession(mux, "/me", cookie)
+	assertTrue(w.Body.String() == "li", "case3", t)
+	time.Sleep(20 * time.Millisecond)
+	w, _ = serveSession(mux, "/me", cookie)
+	assertTrue(w.Body.String() == "<nil>", "case3", t)
+	assertTrue(!strings.Contains(w.Header().Get("Set-Cookie"), cookie.Value), "case3", t)
+}
@@ -0,0 +1,204 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/arging/utils/errors"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// FileServer serves the files of a file system.
+// The directory request is served by its index file, the precompressed
+// ".gz" sidecar is served when the client accepts gzip encoding.
+// Conditional requests and range requests are supported.
+type FileServer struct {
+	FS    fs.FS    // The file system to serve
+	Index []string // Index file names for directory, default is "index.html"
+}
+
+// Serve the directory root under the url prefix of the group.
+// Return the FileServer, so the caller can config it before serving.
+func (g *RouteGroup) Static(prefix string, root string) *FileServer {
+	return g.StaticFS(prefix, os.DirFS(root))
+}
+
+// Serve the file system under the url prefix of the group.
+// The fsys can be any fs.FS, including embed.FS.
+// Return the FileServer, so the caller can config it before serving.
+func (g *RouteGroup) StaticFS(prefix string, fsys fs.FS) *FileServer {
+	s := &FileServer{FS: fsys}
+	url := joinUrl(prefix, pathSep)
+	depth := len(splitPath(joinUrl(g.prefix, url)))
+
+	// A path matches its prefix path, so the url is a catch-all pattern.
+	g.Add([]string{"GET", "HEAD"}, url,
+		func(c *Context) {
+			pieces := splitPath(c.Request.URL.Path)
+			if len(pieces) < depth {
+				pieces = nil
+			} else {
+				pieces = pieces[depth:]
+			}
+			for _, piece := range pieces {
+				if piece == ".." {
+					c.Error(NewHTTPError(http.StatusBadRequest, "invalid url path"))
+					return
+				}
+			}
+
+			found, err := s.serve(c.Response, c.Request, strings.Join(pieces, pathSep))
+			if err != nil {
+				c.Error(err)
+			} else if !found {
+				c.NotFound()
+			}
+		})
+	return s
+}
+
+// Create a handler which serves the named file for any request.
+func (s *FileServer) FileHandler(name string) HandlerFunc {
+	return func(c *Context) {
+		found, err := s.serve(c.Response, c.Request, name)
+		if err != nil {
+			c.Error(err)
+		} else if !found {
+			c.mux.handleNotFound(c)
+		}
+	}
+}
+
+// Serve the named file to the response.
+// Return false, when the file doesn't exist.
+func (s *FileServer) serve(w http.ResponseWriter, r *http.Request, name string) (bool, error) {
+	if name == "" {
+		name = "."
+	}
+	if !fs.ValidPath(name) {
+		return false, nil
+	}
+
+	info, err := fs.Stat(s.FS, name)
+	if err != nil {
+		return false, nil
+	}
+
+	if info.IsDir() {
+		index := s.index(name)
+		if index == "" {
+			return false, nil
+		}
+		// Redirect, so the relative links in the index file work.
+		if !strings.HasSuffix(r.URL.Path, pathSep) {
+			http.Redirect(w, r, path.Base(r.URL.Path)+pathSep, http.StatusMovedPermanently)
+			return true, nil
+		}
+		name = index
+	}
+
+	ctype := mime.TypeByExtension(path.Ext(name))
+	if gzName := name + ".gz"; isFile(s.FS, gzName) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if acceptGzip(r) {
+			if ctype == "" {
+				ctype = "application/octet-stream"
+			}
+			w.Header().Set("Content-Encoding", "gzip")
+			name = gzName
+		}
+	}
+
+	f, err := s.FS.Open(name)
+	if err != nil {
+		return false, nil
+	}
+	defer f.Close()
+
+	if info, err = f.Stat(); err != nil {
+		return false, nil
+	}
+
+	content, etag, err := readSeeker(f, info)
+	if err != nil {
+		return false, errors.Wrapf(err, "read file error: %s.", name)
+	}
+
+	if ctype != "" {
+		w.Header().Set("Content-Type", ctype)
+	}
+	w.Header().Set("Etag", etag)
+	http.ServeContent(w, r, name, info.ModTime(), content)
+	return true, nil
+}
+
+// Get the index file of the directory.
+// Return empty string, when no index file exists.
+func (s *FileServer) index(dir string) string {
+	indexes := s.Index
+	if len(indexes) == 0 {
+		indexes = []string{"index.html"}
+	}
+
+	for _, index := range indexes {
+		name := path.Join(dir, index)
+		if isFile(s.FS, name) {
+			return name
+		}
+	}
+	return ""
+}
+
+// Get the content for http.ServeContent and the strong etag of the file.
+// The etag is made of size and modification time. If the modification time
+// is unknown, such as the file of embed.FS, the etag is the content hash.
+func readSeeker(f fs.File, info fs.FileInfo) (io.ReadSeeker, string, error) {
+	if rs, ok := f.(io.ReadSeeker); ok && !info.ModTime().IsZero() {
+		return rs, fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()), nil
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, "", err
+	}
+
+	h := fnv.New64a()
+	h.Write(data)
+	return bytes.NewReader(data), fmt.Sprintf(`"%x-%x"`, h.Sum64(), len(data)), nil
+}
+
+// Is the name a regular file of the file system.
+func isFile(fsys fs.FS, name string) bool {
+	info, err := fs.Stat(fsys, name)
+	return err == nil && !info.IsDir()
+}
+
+// Is the client accepting gzip encoding.
+func acceptGzip(r *http.Request) bool {
+	for _, v := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		parts := strings.Split(v, ";")
+		if strings.TrimSpace(parts[0]) != "gzip" {
+			continue
+		}
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				q, err := strconv.ParseFloat(param[2:], 64)
+				return err == nil && q > 0
+			}
+		}
+		return true
+	}
+	return false
+}
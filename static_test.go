@@ -0,0 +1,118 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"net/http"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func testFS() fstest.MapFS {
+	modTime := time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)
+	return fstest.MapFS{
+		"index.html":     {Data: []byte("<p>index</p>"), ModTime: modTime},
+		"css/site.css":   {Data: []byte("body{}"), ModTime: modTime},
+		"js/app.js":      {Data: []byte("var app;"), ModTime: modTime},
+		"js/app.js.gz":   {Data: []byte("gzipped"), ModTime: modTime},
+		"docs/readme.md": {Data: []byte("0123456789")},
+	}
+}
+
+func TestStatic(t *testing.T) {
+	mux := NewMux("myMux")
+	mux.StaticFS("/static", testFS())
+	assertTrue(mux.Start() == nil, "case0", t)
+
+	// index file
+	w1 := serve(mux, "GET", "/static/")
+	assertTrue(w1.Code == 200 && w1.Body.String() == "<p>index</p>", "case1", t)
+	w2 := serve(mux, "GET", "/static")
+	assertTrue(w2.Code == http.StatusMovedPermanently, "case2", t)
+	assertTrue(w2.Header().Get("Location") == "/static/", "case2", t)
+
+	// content type
+	w3 := serve(mux, "GET", "/static/css/site.css")
+	assertTrue(w3.Body.String() == "body{}", "case3", t)
+	assertTrue(w3.Header().Get("Content-Type") == "text/css; charset=utf-8", "case3", t)
+	assertTrue(w3.Header().Get("Last-Modified") != "", "case3", t)
+
+	// not found
+	w4 := serve(mux, "GET", "/static/css/none.css")
+	assertTrue(w4.Code == http.StatusNotFound, "case4", t)
+	w5 := serve(mux, "GET", "/static/docs")
+	assertTrue(w5.Code == http.StatusNotFound, "case5", t)
+	w6 := serve(mux, "POST", "/static/index.html")
+	assertTrue(w6.Code == http.StatusMethodNotAllowed && w6.Header().Get("Allow") == "GET, HEAD", "case6", t)
+
+	// group prefix
+	mux.Group("/assets").StaticFS("/v1", testFS())
+	assertTrue(mux.Start() == nil, "case7", t)
+	w7 := serve(mux, "GET", "/assets/v1/css/site.css")
+	assertTrue(w7.Body.String() == "body{}", "case7", t)
+}
+
+func TestStaticTraversal(t *testing.T) {
+	mux := NewMux("myMux")
+	mux.StaticFS("/static", testFS())
+	mux.Start()
+
+	r1 := serve(mux, "GET", "/static/../static/index.html")
+	assertTrue(r1.Code == http.StatusBadRequest, "case1", t)
+	r2 := serve(mux, "GET", "/static/css/..//../index.html")
+	assertTrue(r2.Code == http.StatusBadRequest, "case2", t)
+}
+
+func TestStaticConditional(t *testing.T) {
+	mux := NewMux("myMux")
+	mux.StaticFS("/", testFS())
+	mux.Start()
+
+	w1 := serve(mux, "GET", "/css/site.css")
+	etag := w1.Header().Get("Etag")
+	assertTrue(etag != "", "case1", t)
+
+	w2 := serve(mux, "GET", "/css/site.css", "If-None-Match", etag)
+	assertTrue(w2.Code == http.StatusNotModified, "case2", t)
+
+	w3 := serve(mux, "GET", "/css/site.css", "If-Modified-Since", w1.Header().Get("Last-Modified"))
+	assertTrue(w3.Code == http.StatusNotModified, "case3", t)
+
+	// etag by content hash, when modification time is unknown
+	w4 := serve(mux, "GET", "/docs/readme.md")
+	assertTrue(w4.Header().Get("Etag") != "", "case4", t)
+	w5 := serve(mux, "GET", "/docs/readme.md", "If-None-Match", w4.Header().Get("Etag"))
+	assertTrue(w5.Code == http.StatusNotModified, "case5", t)
+}
+
+func TestStaticRange(t *testing.T) {
+	mux := NewMux("myMux")
+	mux.StaticFS("/", testFS())
+	mux.Start()
+
+	w1 := serve(mux, "GET", "/docs/readme.md", "Range", "bytes=2-4")
+	assertTrue(w1.Code == http.StatusPartialContent, "case1", t)
+	assertTrue(w1.Body.String() == "234", "case1", t)
+}
+
+func TestStaticGzip(t *testing.T) {
+	mux := NewMux("myMux")
+	mux.StaticFS("/", testFS())
+	mux.Start()
+
+	w1 := serve(mux, "GET", "/js/app.js", "Accept-Encoding", "gzip, deflate")
+	assertTrue(w1.Body.String() == "gzipped", "case1", t)
+	assertTrue(w1.Header().Get("Content-Encoding") == "gzip", "case1", t)
+	assertTrue(w1.Header().Get("Vary") == "Accept-Encoding", "case1", t)
+	assertTrue(w1.Header().Get("Content-Type") == "text/javascript; charset=utf-8", "case1", t)
+
+	w2 := serve(mux, "GET", "/js/app.js")
+	assertTrue(w2.Body.String() == "var app;", "case2", t)
+	assertTrue(w2.Header().Get("Content-Encoding") == "", "case2", t)
+
+	w3 := serve(mux, "GET", "/js/app.js", "Accept-Encoding", "gzip;q=0")
+	assertTrue(w3.Body.String() == "var app;", "case3", t)
+}
@@ -0,0 +1,68 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package trace
+
+import (
+	"sync"
+)
+
+var _ Exporter = &MemoryExporter{}
+
+// Exporter sends the ended spans to a tracing system.
+// Export is called when the span ends, so it should not block for long.
+type Exporter interface {
+	Export(span *Span)
+}
+
+// ExporterFunc is a func as an Exporter.
+type ExporterFunc func(span *Span)
+
+func (f ExporterFunc) Export(span *Span) {
+	f(span)
+}
+
+// MemoryExporter keeps the ended spans in memory, it is useful for tests.
+type MemoryExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+// Create an empty memory exporter.
+func NewMemoryExporter() *MemoryExporter {
+	return &MemoryExporter{}
+}
+
+func (e *MemoryExporter) Export(span *Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// Get the exported spans in ending order.
+func (e *MemoryExporter) Spans() []*Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]*Span(nil), e.spans...)
+}
+
+// Get the first exported span by name.
+// Return nil, when no span has the name.
+func (e *MemoryExporter) Span(name string) *Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, span := range e.spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	return nil
+}
+
+// Drop all exported spans.
+func (e *MemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = nil
+}
@@ -0,0 +1,253 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+// Package trace is the distributed tracing module for light framework.
+//
+// A span records a timed operation, and the spans of a request share the
+// same trace ID. The span is passed through the context.Context, and it is
+// propagated across services by the W3C "traceparent" header:
+//
+//	exporter := trace.NewMemoryExporter()
+//	tracer := trace.NewTracer(exporter)
+//
+//	ctx, span := tracer.Start(ctx, "query users")
+//	defer span.End()
+//	trace.Inject(ctx, req.Header)
+//
+// The ended spans are sent to the Exporter, implement it to send the spans
+// to your tracing system.
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// TraceID identifies a trace, it is 16 bytes.
+type TraceID [16]byte
+
+// SpanID identifies a span in the trace, it is 8 bytes.
+type SpanID [8]byte
+
+// Get the lowercase hex string of the trace ID.
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// Is the trace ID valid, the all zero ID is invalid.
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+// Get the lowercase hex string of the span ID.
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// Is the span ID valid, the all zero ID is invalid.
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// SpanContext is the part of a span propagated to other services.
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Sampled    bool   // Is the trace sampled by the caller
+	TraceState string // The vendor data of "tracestate" header, passed as is
+	Remote     bool   // Is the span context from another service
+}
+
+// Is the span context valid, both of the IDs are valid.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Span is a timed operation of a trace.
+// The methods of Span are safe for concurrent use.
+type Span struct {
+	Name   string
+	Parent SpanContext // The parent span context, invalid for the root span
+
+	context SpanContext
+	tracer  *Tracer
+
+	mu         sync.Mutex
+	start      time.Time
+	end        time.Time
+	attributes map[string]interface{}
+	err        string
+}
+
+// Get the span context of the span.
+func (s *Span) Context() SpanContext {
+	return s.context
+}
+
+// Get the start time of the span.
+func (s *Span) Start() time.Time {
+	return s.start
+}
+
+// Get the end time of the span. It is zero, when the span isn't ended.
+func (s *Span) EndTime() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.end
+}
+
+// Get the duration of the span. It is zero, when the span isn't ended.
+func (s *Span) Duration() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.end.IsZero() {
+		return 0
+	}
+	return s.end.Sub(s.start)
+}
+
+// Set the attribute of the span, such as "http.route".
+func (s *Span) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attributes == nil {
+		s.attributes = make(map[string]interface{})
+	}
+	s.attributes[key] = value
+}
+
+// Get the attribute value of the span.
+func (s *Span) Attribute(key string) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.attributes[key]
+	return value, ok
+}
+
+// Get a copy of the attributes of the span.
+func (s *Span) Attributes() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attrs := make(map[string]interface{}, len(s.attributes))
+	for k, v := range s.attributes {
+		attrs[k] = v
+	}
+	return attrs
+}
+
+// Mark the span failed by the error description.
+func (s *Span) SetError(description string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = description
+}
+
+// Get the error description of the span.
+// Return empty string, when the span isn't failed.
+func (s *Span) Error() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// End the span now, see EndAt.
+func (s *Span) End() {
+	s.EndAt(time.Now())
+}
+
+// End the span at the time, and export it if it is sampled.
+// Only the first call takes effect.
+func (s *Span) EndAt(t time.Time) {
+	s.mu.Lock()
+	if !s.end.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	s.end = t
+	s.mu.Unlock()
+
+	if s.context.Sampled && s.tracer.exporter != nil {
+		s.tracer.exporter.Export(s)
+	}
+}
+
+// Tracer starts the spans, and exports them to the Exporter when they end.
+type Tracer struct {
+	exporter Exporter
+}
+
+// Create a tracer by the exporter.
+// If the exporter is nil, the spans are not exported.
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+// Start a span now, see StartAt.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	return t.StartAt(ctx, name, time.Now())
+}
+
+// Start a span at the time, and return the context with the span.
+// The span in the context, or the remote span context set by WithRemote,
+// is the parent. Otherwise, the span is the root of a new sampled trace.
+func (t *Tracer) StartAt(ctx context.Context, name string, start time.Time) (context.Context, *Span) {
+	span := &Span{Name: name, tracer: t, start: start}
+
+	if parent := FromContext(ctx); parent != nil {
+		span.Parent = parent.context
+	} else if remote, ok := ctx.Value(remoteKey{}).(SpanContext); ok {
+		span.Parent = remote
+	}
+
+	if span.Parent.IsValid() {
+		span.context = SpanContext{
+			TraceID:    span.Parent.TraceID,
+			Sampled:    span.Parent.Sampled,
+			TraceState: span.Parent.TraceState,
+		}
+	} else {
+		span.context = SpanContext{Sampled: true}
+		randomID(span.context.TraceID[:])
+	}
+	randomID(span.context.SpanID[:])
+	return NewContext(ctx, span), span
+}
+
+// Fill the ID by random bytes, the result is never all zero.
+func randomID(id []byte) {
+	for {
+		rand.Read(id)
+		for _, b := range id {
+			if b != 0 {
+				return
+			}
+		}
+	}
+}
+
+// The context keys.
+type spanKey struct{}
+type remoteKey struct{}
+
+// Create a context with the span.
+func NewContext(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// Get the span in the context.
+// Return nil, when no span in the context.
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// Create a context with the remote span context, such as parsed from the
+// "traceparent" header. The next span started by a Tracer continues it.
+func WithRemote(ctx context.Context, sc SpanContext) context.Context {
+	sc.Remote = true
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
@@ -0,0 +1,80 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package trace
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func assertTrue(b bool, msg string, t *testing.T) {
+	if !b {
+		t.Error(msg)
+	}
+}
+
+func TestTracer(t *testing.T) {
+	exporter := NewMemoryExporter()
+	tracer := NewTracer(exporter)
+
+	ctx, root := tracer.Start(context.Background(), "root")
+	assertTrue(FromContext(ctx) == root, "case1", t)
+	assertTrue(root.Context().IsValid() && root.Context().Sampled, "case1", t)
+	assertTrue(!root.Parent.IsValid(), "case1", t)
+
+	_, child := tracer.Start(ctx, "child")
+	assertTrue(child.Context().TraceID == root.Context().TraceID, "case2", t)
+	assertTrue(child.Context().SpanID != root.Context().SpanID, "case2", t)
+	assertTrue(child.Parent == root.Context(), "case2", t)
+
+	// exported when ended, only once
+	child.SetAttribute("k", 1)
+	child.End()
+	child.End()
+	assertTrue(len(exporter.Spans()) == 1 && exporter.Span("child") == child, "case3", t)
+	assertTrue(child.Attributes()["k"] == 1 && child.Duration() >= 0, "case3", t)
+	assertTrue(root.Duration() == 0, "case3", t)
+
+	start := time.Now().Add(-time.Second)
+	_, span := tracer.StartAt(ctx, "past", start)
+	span.EndAt(start.Add(time.Millisecond))
+	assertTrue(span.Duration() == time.Millisecond, "case4", t)
+
+	// no exporter
+	_, span = NewTracer(nil).Start(context.Background(), "none")
+	span.End()
+	assertTrue(span.EndTime().After(span.Start()), "case5", t)
+}
+
+func TestRemote(t *testing.T) {
+	exporter := NewMemoryExporter()
+	tracer := NewTracer(exporter)
+
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	header.Add("tracestate", "a=1")
+	header.Add("tracestate", "b=2")
+
+	ctx, span := tracer.Start(Extract(context.Background(), header), "server")
+	assertTrue(span.Context().TraceID.String() == "4bf92f3577b34da6a3ce929d0e0e4736", "case1", t)
+	assertTrue(span.Parent.Remote && !span.Context().Sampled, "case1", t)
+	assertTrue(span.Context().TraceState == "a=1,b=2", "case1", t)
+
+	// the span isn't sampled, so it isn't exported
+	span.End()
+	assertTrue(len(exporter.Spans()) == 0, "case2", t)
+
+	out := http.Header{}
+	Inject(ctx, out)
+	assertTrue(out.Get("traceparent") == "00-4bf92f3577b34da6a3ce929d0e0e4736-"+span.Context().SpanID.String()+"-00", "case3", t)
+	assertTrue(out.Get("tracestate") == "a=1,b=2", "case3", t)
+
+	out = http.Header{}
+	Inject(context.Background(), out)
+	assertTrue(len(out) == 0, "case4", t)
+	assertTrue(Extract(context.Background(), out) == context.Background(), "case4", t)
+}
@@ -0,0 +1,115 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package trace
+
+import (
+	"context"
+	"encoding/hex"
+	"github.com/arging/utils/errors"
+	"net/http"
+	"strings"
+)
+
+// The headers of W3C Trace Context.
+const (
+	HeaderTraceparent = "traceparent"
+	HeaderTracestate  = "tracestate"
+)
+
+// The supported version of "traceparent" header.
+const traceparentVersion = "00"
+
+// The flag of sampled trace.
+const flagSampled = 0x01
+
+// Parse the "traceparent" header, such as:
+//
+//	00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+//
+// The header of a future version is parsed by the fields of version 00.
+func ParseTraceparent(header string) (SpanContext, errors.Error) {
+	var sc SpanContext
+	header = strings.TrimSpace(header)
+	if len(header) < 55 {
+		return sc, errors.Newf("traceparent error, too short: %s.", header)
+	}
+
+	version := header[:2]
+	if !isLowerHex(version) || version == "ff" {
+		return sc, errors.Newf("traceparent error, invalid version: %s.", header)
+	}
+	if version == traceparentVersion && len(header) != 55 {
+		return sc, errors.Newf("traceparent error, invalid length: %s.", header)
+	}
+	if len(header) > 55 && header[55] != '-' {
+		return sc, errors.Newf("traceparent error, invalid format: %s.", header)
+	}
+	if header[2] != '-' || header[35] != '-' || header[52] != '-' {
+		return sc, errors.Newf("traceparent error, invalid format: %s.", header)
+	}
+
+	traceID, spanID, flags := header[3:35], header[36:52], header[53:55]
+	if !isLowerHex(traceID) || !isLowerHex(spanID) || !isLowerHex(flags) {
+		return sc, errors.Newf("traceparent error, invalid hex: %s.", header)
+	}
+	hex.Decode(sc.TraceID[:], []byte(traceID))
+	hex.Decode(sc.SpanID[:], []byte(spanID))
+	if !sc.IsValid() {
+		return sc, errors.Newf("traceparent error, zero id: %s.", header)
+	}
+
+	var f [1]byte
+	hex.Decode(f[:], []byte(flags))
+	sc.Sampled = f[0]&flagSampled != 0
+	return sc, nil
+}
+
+// Get the "traceparent" header of the span context.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return traceparentVersion + "-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// Extract the remote span context from the headers, and create a context
+// with it, see WithRemote. Return the origin context, when the headers have
+// no valid "traceparent".
+func Extract(ctx context.Context, header http.Header) context.Context {
+	sc, err := ParseTraceparent(header.Get(HeaderTraceparent))
+	if err != nil {
+		return ctx
+	}
+	sc.TraceState = strings.Join(header.Values(HeaderTracestate), ",")
+	return WithRemote(ctx, sc)
+}
+
+// Inject the span of the context into the headers, so the trace is continued
+// by the called service. Nothing is injected, when no span in the context.
+func Inject(ctx context.Context, header http.Header) {
+	span := FromContext(ctx)
+	if span == nil {
+		return
+	}
+	sc := span.Context()
+	header.Set(HeaderTraceparent, sc.Traceparent())
+	if sc.TraceState != "" {
+		header.Set(HeaderTracestate, sc.TraceState)
+	} else {
+		header.Del(HeaderTracestate)
+	}
+}
+
+// Is the string only lowercase hex characters.
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package trace
+
+import (
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	header := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, err := ParseTraceparent(header)
+	assertTrue(err == nil && sc.Sampled, "case1", t)
+	assertTrue(sc.TraceID.String() == "4bf92f3577b34da6a3ce929d0e0e4736", "case1", t)
+	assertTrue(sc.SpanID.String() == "00f067aa0ba902b7", "case1", t)
+	assertTrue(sc.Traceparent() == header, "case1", t)
+
+	// future version with more fields
+	sc, err = ParseTraceparent("cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-xyz")
+	assertTrue(err == nil && !sc.Sampled, "case2", t)
+
+	invalids := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-xyz",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00_4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01xyz",
+	}
+	for i, h := range invalids {
+		_, err := ParseTraceparent(h)
+		if err == nil {
+			t.Errorf("case3 %d", i)
+		}
+	}
+}
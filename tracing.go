@@ -0,0 +1,76 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"fmt"
+	"github.com/uestcer/light/trace"
+	"net/http"
+)
+
+// The name of routing span.
+const routingSpan = "routing"
+
+// Tracing starts a span for each request, it is named after the matched route
+// pattern, such as "GET /users/(id)". The span continues the trace of the
+// "traceparent" header, and the routing time is recorded as a child span.
+//
+// The span is passed through the request context, so the handlers can start
+// child spans and propagate the trace to other services:
+//
+//	ctx, span := tracer.Start(c.Request.Context(), "query users")
+//	defer span.End()
+//	trace.Inject(ctx, req.Header)
+//
+// It should be global middleware, so the not found requests are also traced.
+func Tracing(tracer *trace.Tracer) HandlerFunc {
+	return func(c *Context) {
+		r := c.Request
+		route := ""
+		if c.Result != nil && c.Result.IsMatch {
+			route = c.Result.Url
+		}
+		name := r.Method
+		if route != "" {
+			name += " " + route
+		}
+
+		ctx := trace.Extract(r.Context(), r.Header)
+		ctx, span := tracer.StartAt(ctx, name, c.start)
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.target", r.URL.RequestURI())
+		if route != "" {
+			span.SetAttribute("http.route", route)
+		}
+		_, routing := tracer.StartAt(ctx, routingSpan, c.start)
+		routing.SetAttribute("route.matched", route != "")
+		routing.EndAt(c.routed)
+
+		c.Request = r.WithContext(ctx)
+		defer func() {
+			// The panic is recorded and passed to the outer Recovery.
+			if p := recover(); p != nil {
+				span.SetAttribute("http.status_code", http.StatusInternalServerError)
+				span.SetError(fmt.Sprintf("panic: %v", p))
+				span.End()
+				panic(p)
+			}
+
+			status := c.Response.Status()
+			span.SetAttribute("http.status_code", status)
+			if status >= http.StatusInternalServerError {
+				span.SetError(http.StatusText(status))
+			}
+			span.End()
+		}()
+		c.Next()
+	}
+}
+
+// Get the span of the request started by Tracing.
+// Return nil, when the request isn't traced.
+func (c *Context) Span() *trace.Span {
+	return trace.FromContext(c.Request.Context())
+}
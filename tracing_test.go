@@ -0,0 +1,90 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package light
+
+import (
+	"github.com/uestcer/light/trace"
+	"net/http"
+	"testing"
+)
+
+func tracingMux(tracer *trace.Tracer) *Mux {
+	mux := NewMux("myMux")
+	mux.Use(Recovery(), Tracing(tracer))
+	mux.Add([]string{"GET"}, "/users/(id)", func(c *Context) {
+		_, span := tracer.Start(c.Request.Context(), "query")
+		span.End()
+		c.Text(http.StatusOK, c.Span().Context().TraceID.String())
+	})
+	mux.Add([]string{"GET"}, "/panic", func(c *Context) {
+		panic("boom")
+	})
+	mux.Start()
+	return mux
+}
+
+func TestTracing(t *testing.T) {
+	exporter := trace.NewMemoryExporter()
+	mux := tracingMux(trace.NewTracer(exporter))
+
+	w := serve(mux, "GET", "/users/1")
+	spans := exporter.Spans()
+	assertTrue(len(spans) == 3, "case1", t)
+	routing, query, span := spans[0], spans[1], spans[2]
+	assertTrue(span.Name == "GET /users/(id)" && !span.Parent.IsValid(), "case1", t)
+	assertTrue(w.Body.String() == span.Context().TraceID.String(), "case1", t)
+
+	// routing and handler spans are children of the request span
+	assertTrue(routing.Name == "routing" && query.Name == "query", "case2", t)
+	assertTrue(routing.Parent.SpanID == span.Context().SpanID, "case2", t)
+	assertTrue(query.Parent.SpanID == span.Context().SpanID, "case2", t)
+	assertTrue(routing.Start() == span.Start(), "case2", t)
+	assertFalse(routing.EndTime().After(span.EndTime()), "case2", t)
+
+	route, _ := span.Attribute("http.route")
+	status, _ := span.Attribute("http.status_code")
+	assertTrue(route == "/users/(id)" && status == http.StatusOK, "case3", t)
+	assertTrue(span.Error() == "", "case3", t)
+}
+
+func TestTracingTraceparent(t *testing.T) {
+	exporter := trace.NewMemoryExporter()
+	mux := tracingMux(trace.NewTracer(exporter))
+
+	parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	serve(mux, "GET", "/users/1", "traceparent", parent, "tracestate", "a=1")
+	span := exporter.Span("GET /users/(id)")
+	assertTrue(span.Context().TraceID.String() == "4bf92f3577b34da6a3ce929d0e0e4736", "case1", t)
+	assertTrue(span.Parent.SpanID.String() == "00f067aa0ba902b7" && span.Parent.Remote, "case1", t)
+	assertTrue(span.Context().TraceState == "a=1", "case1", t)
+
+	// not sampled by the caller
+	exporter.Reset()
+	serve(mux, "GET", "/users/1", "traceparent", parent[:53]+"00")
+	assertTrue(len(exporter.Spans()) == 0, "case2", t)
+
+	// the invalid header starts a new trace
+	serve(mux, "GET", "/users/1", "traceparent", "00-xyz")
+	span = exporter.Span("GET /users/(id)")
+	assertTrue(span != nil && !span.Parent.IsValid(), "case3", t)
+}
+
+func TestTracingError(t *testing.T) {
+	exporter := trace.NewMemoryExporter()
+	mux := tracingMux(trace.NewTracer(exporter))
+
+	w := serve(mux, "GET", "/panic")
+	span := exporter.Span("GET /panic")
+	assertTrue(w.Code == http.StatusInternalServerError, "case1", t)
+	assertTrue(span.Error() == "panic: boom", "case1", t)
+
+	serve(mux, "GET", "/none")
+	span = exporter.Span("GET")
+	status, _ := span.Attribute("http.status_code")
+	matched, _ := exporter.Spans()[2].Attribute("route.matched")
+	assertTrue(status == http.StatusNotFound && matched == false, "case2", t)
+	_, ok := span.Attribute("http.route")
+	assertFalse(ok, "case2", t)
+}
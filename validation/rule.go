@@ -0,0 +1,116 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package validation
+
+import (
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9.!#$%&'*+/=?^_{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+
+// The built-in rules, "required" is handled by the Validator.
+var builtinRules = map[string]Func{
+	"min":   minRule,
+	"max":   maxRule,
+	"len":   lenRule,
+	"email": emailRule,
+	"url":   urlRule,
+	"oneof": oneofRule,
+}
+
+// DefaultMessages is the english messages for the built-in rules.
+// The message of "" is used for the rule without message.
+var DefaultMessages = map[string]string{
+	"":         "{field} is invalid",
+	"required": "{field} is required",
+	"min":      "{field} must be at least {param}",
+	"max":      "{field} must be at most {param}",
+	"len":      "{field} must have length {param}",
+	"email":    "{field} must be a valid email address",
+	"url":      "{field} must be a valid url",
+	"oneof":    "{field} must be one of [{param}]",
+	"int":      "{field} must be an integer",
+	"uint":     "{field} must be a non-negative integer",
+	"alpha":    "{field} must contain only letters",
+	"alnum":    "{field} must contain only letters and digits",
+	"hex":      "{field} must be a hexadecimal string",
+	"slug":     "{field} must be a slug",
+	"uuid":     "{field} must be a valid uuid",
+}
+
+// Compare the value with the param.
+// The length of string, slice and map is compared, and the string length
+// is counted by runes. Return false, when the value is not comparable.
+func compare(v reflect.Value, param string, fn func(float64, float64) bool) bool {
+	var n float64
+	switch v.Kind() {
+	case reflect.String:
+		n = float64(utf8.RuneCountInString(v.String()))
+	case reflect.Slice, reflect.Map, reflect.Array:
+		n = float64(v.Len())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = v.Float()
+	default:
+		return false
+	}
+
+	p, err := strconv.ParseFloat(param, 64)
+	return err == nil && fn(n, p)
+}
+
+func minRule(v reflect.Value, param string) bool {
+	return compare(v, param, func(n, p float64) bool { return n >= p })
+}
+
+func maxRule(v reflect.Value, param string) bool {
+	return compare(v, param, func(n, p float64) bool { return n <= p })
+}
+
+func lenRule(v reflect.Value, param string) bool {
+	return compare(v, param, func(n, p float64) bool { return n == p })
+}
+
+func emailRule(v reflect.Value, param string) bool {
+	return v.Kind() == reflect.String && emailRegex.MatchString(v.String())
+}
+
+func urlRule(v reflect.Value, param string) bool {
+	if v.Kind() != reflect.String {
+		return false
+	}
+	u, err := url.ParseRequestURI(v.String())
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// The param is the options separated by space.
+func oneofRule(v reflect.Value, param string) bool {
+	var s string
+	switch v.Kind() {
+	case reflect.String:
+		s = v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s = strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s = strconv.FormatUint(v.Uint(), 10)
+	default:
+		return false
+	}
+
+	for _, option := range strings.Fields(param) {
+		if s == option {
+			return true
+		}
+	}
+	return false
+}
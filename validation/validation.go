@@ -0,0 +1,262 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+// Package validation validates struct fields by the "validate" tag.
+//
+// The rules are separated by ",", and the rule param follows "=":
+//
+//	type User struct {
+//		Name  string `validate:"required,min=2,max=20"`
+//		Age   int    `validate:"min=18,max=150"`
+//		Email string `validate:"required,email"`
+//		Site  string `validate:"url"`
+//		Role  string `validate:"oneof=admin user guest"`
+//		Id    string `validate:"uuid"`
+//	}
+//
+// The named constraints of the router, such as "int", "slug" and "uuid",
+// are also rules, so the path params and the struct fields share the
+// same constraints. Except "required", the rules skip the unset value, which
+// is the nil pointer, slice, map or interface, or the empty string. The zero
+// numbers are checked, so "min=1" rejects 0.
+package validation
+
+import (
+	"fmt"
+	"github.com/arging/utils/errors"
+	"github.com/uestcer/light/router"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+const (
+	tagName  = "validate" // Struct tag for rules
+	ruleSep  = ","        // Separator for rules
+	paramSep = "="        // Separator for rule name and param
+)
+
+// Func checks the field value with the rule param.
+// Return false, when the value is invalid.
+type Func func(v reflect.Value, param string) bool
+
+// FieldError is the error for an invalid struct field.
+type FieldError struct {
+	Field   string      // The struct field name, nested field is joined by "."
+	Rule    string      // The failed rule name
+	Param   string      // The rule param
+	Value   interface{} // The field value
+	Message string      // The message for the error
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+// Errors is the errors of all invalid fields.
+type Errors []*FieldError
+
+func (errs Errors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Get the messages by field name.
+func (errs Errors) Fields() map[string]string {
+	fields := make(map[string]string, len(errs))
+	for _, err := range errs {
+		if _, ok := fields[err.Field]; !ok {
+			fields[err.Field] = err.Message
+		}
+	}
+	return fields
+}
+
+// Validator validates struct by rules, and makes messages for invalid fields.
+// Validator is safe for concurrent use.
+type Validator struct {
+	mu       sync.RWMutex
+	rules    map[string]Func
+	messages map[string]string
+}
+
+// Create a validator with the built-in rules and messages.
+func New() *Validator {
+	v := &Validator{
+		rules:    make(map[string]Func, len(builtinRules)),
+		messages: make(map[string]string, len(DefaultMessages)),
+	}
+	for name, fn := range builtinRules {
+		v.rules[name] = fn
+	}
+	v.SetMessages(DefaultMessages)
+	return v
+}
+
+// Register the custom rule with its message.
+// The message can use "{field}" and "{param}" placeholders.
+func (v *Validator) Register(name string, fn Func, message string) {
+	v.mu.Lock()
+	v.rules[name] = fn
+	v.messages[name] = message
+	v.mu.Unlock()
+}
+
+// Set the messages by rule name, such as the translated messages.
+// The messages of other rules are unchanged.
+func (v *Validator) SetMessages(messages map[string]string) {
+	v.mu.Lock()
+	for name, message := range messages {
+		v.messages[name] = message
+	}
+	v.mu.Unlock()
+}
+
+// Validate the struct or struct pointer s.
+// Return Errors of all invalid fields, or nil when s is valid.
+func (v *Validator) Validate(s interface{}) error {
+	rv := reflect.ValueOf(s)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return errors.Newf("validate target must be a struct, got %T.", s)
+	}
+
+	var errs Errors
+	if err := v.validateStruct(rv, "", &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (v *Validator) validateStruct(rv reflect.Value, prefix string, errs *Errors) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		field := rv.Field(i)
+		name := prefix + sf.Name
+
+		if tag := sf.Tag.Get(tagName); tag != "" && tag != "-" {
+			if err := v.validateField(field, name, tag, errs); err != nil {
+				return err
+			}
+		}
+
+		for field.Kind() == reflect.Ptr && !field.IsNil() {
+			field = field.Elem()
+		}
+		if field.Kind() == reflect.Struct {
+			if err := v.validateStruct(field, name+".", errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (v *Validator) validateField(field reflect.Value, name string, tag string, errs *Errors) error {
+	zero, unset := field.IsZero(), isUnset(field)
+	for field.Kind() == reflect.Ptr && !field.IsNil() {
+		field = field.Elem()
+	}
+
+	for _, rule := range strings.Split(tag, ruleSep) {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		ruleName, param := rule, ""
+		if i := strings.Index(rule, paramSep); i != -1 {
+			ruleName, param = rule[:i], rule[i+1:]
+		}
+
+		if ruleName == "required" {
+			if zero {
+				*errs = append(*errs, v.fieldError(name, ruleName, param, field))
+				return nil
+			}
+			continue
+		}
+		if unset {
+			continue
+		}
+
+		fn, err := v.rule(ruleName)
+		if err != nil {
+			return errors.Wrapf(err, "validate field %s error.", name)
+		}
+		if !fn(field, param) {
+			*errs = append(*errs, v.fieldError(name, ruleName, param, field))
+		}
+	}
+	return nil
+}
+
+// Is the field unset, so the rules except "required" skip it.
+func isUnset(field reflect.Value) bool {
+	switch field.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		return field.IsNil()
+	case reflect.String:
+		return field.Len() == 0
+	}
+	return false
+}
+
+// Get the rule by name, the router constraints are also rules.
+func (v *Validator) rule(name string) (Func, errors.Error) {
+	v.mu.RLock()
+	fn, ok := v.rules[name]
+	v.mu.RUnlock()
+	if ok {
+		return fn, nil
+	}
+
+	if regex, ok := router.Constraint(name); ok {
+		return func(v reflect.Value, param string) bool {
+			return regex.MatchString(fmt.Sprint(v.Interface()))
+		}, nil
+	}
+	return nil, errors.Newf("unknown validation rule: %s.", name)
+}
+
+func (v *Validator) fieldError(name string, rule string, param string, field reflect.Value) *FieldError {
+	v.mu.RLock()
+	message, ok := v.messages[rule]
+	if !ok {
+		message = v.messages[""]
+	}
+	v.mu.RUnlock()
+
+	message = strings.NewReplacer("{field}", name, "{param}", param, "{rule}", rule).Replace(message)
+	var value interface{}
+	if field.IsValid() && field.CanInterface() {
+		value = field.Interface()
+	}
+	return &FieldError{name, rule, param, value, message}
+}
+
+// The default validator.
+var Default = New()
+
+// Validate the struct by the default validator.
+func Validate(s interface{}) error {
+	return Default.Validate(s)
+}
+
+// Register the custom rule to the default validator.
+func Register(name string, fn Func, message string) {
+	Default.Register(name, fn, message)
+}
@@ -0,0 +1,153 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package validation
+
+import (
+	"github.com/arging/utils/errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func assertTrue(rs bool, msg string, t *testing.T) {
+	if !rs {
+		// Track the test error source.
+		t.Error(errors.New(msg))
+	}
+}
+func assertFalse(rs bool, msg string, t *testing.T) {
+	assertTrue(!rs, msg, t)
+}
+
+type address struct {
+	City string `validate:"required"`
+}
+
+type user struct {
+	Name    string   `validate:"required,min=2,max=5"`
+	Age     int      `validate:"min=18,max=150"`
+	Email   string   `validate:"required,email"`
+	Site    string   `validate:"url"`
+	Role    string   `validate:"oneof=admin user"`
+	Id      string   `validate:"uuid"`
+	Slug    string   `validate:"slug"`
+	Tags    []string `validate:"max=2"`
+	Score   *float64 `validate:"required,min=0"`
+	Address *address
+}
+
+func validUser() *user {
+	score := 1.5
+	return &user{
+		Name:    "李雷",
+		Age:     20,
+		Email:   "li@example.com",
+		Site:    "http://example.com",
+		Role:    "admin",
+		Id:      "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+		Slug:    "hello-world",
+		Tags:    []string{"a"},
+		Score:   &score,
+		Address: &address{"Chengdu"},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assertTrue(Validate(validUser()) == nil, "case1", t)
+	assertTrue(Validate(*validUser()) == nil, "case1", t)
+
+	// unset value skips rules except required
+	u2 := validUser()
+	u2.Site, u2.Role, u2.Tags, u2.Address = "", "", nil, nil
+	assertTrue(Validate(u2) == nil, "case2", t)
+
+	// zero number is checked
+	u2.Age = 0
+	errs, ok := Validate(u2).(Errors)
+	assertTrue(ok && len(errs) == 1 && errs[0].Field == "Age" && errs[0].Rule == "min", "case2", t)
+	var item struct {
+		Qty int `validate:"min=1"`
+	}
+	assertTrue(Validate(&item) != nil, "case2", t)
+
+	assertTrue(Validate("user") != nil, "case3", t)
+}
+
+func TestValidateErrors(t *testing.T) {
+	score := -1.0
+	u := &user{
+		Name:    "a",
+		Age:     200,
+		Email:   "li@",
+		Site:    "example.com",
+		Role:    "root",
+		Id:      "123",
+		Slug:    "Hello World",
+		Tags:    []string{"a", "b", "c"},
+		Score:   &score,
+		Address: &address{},
+	}
+
+	err := Validate(u)
+	errs, ok := err.(Errors)
+	assertTrue(ok && len(errs) == 10, "case1", t)
+
+	rules := make([]string, len(errs))
+	for i, e := range errs {
+		rules[i] = e.Field + ":" + e.Rule
+	}
+	expected := []string{"Name:min", "Age:max", "Email:email", "Site:url", "Role:oneof",
+		"Id:uuid", "Slug:slug", "Tags:max", "Score:min", "Address.City:required"}
+	assertTrue(reflect.DeepEqual(rules, expected), "case2", t)
+
+	assertTrue(errs[0].Message == "Name must be at least 2", "case3", t)
+	assertTrue(errs[0].Param == "2" && errs[0].Value == "a", "case3", t)
+	assertTrue(errs.Fields()["Role"] == "Role must be one of [admin user]", "case3", t)
+
+	errs2 := Validate(&user{}).(Errors)
+	assertTrue(len(errs2) == 4 && errs2[1].Field == "Age" && errs2[1].Rule == "min", "case4", t)
+	assertTrue(errs2[3].Field == "Score" && errs2[3].Rule == "required", "case4", t)
+}
+
+func TestValidatorRegister(t *testing.T) {
+	v := New()
+	v.Register("even", func(rv reflect.Value, param string) bool {
+		return rv.Int()%2 == 0
+	}, "{field} must be even")
+
+	s := struct {
+		Num int `validate:"even"`
+	}{3}
+	errs := v.Validate(s).(Errors)
+	assertTrue(errs[0].Message == "Num must be even", "case1", t)
+
+	// unknown rule
+	s2 := struct {
+		Num int `validate:"none"`
+	}{3}
+	_, ok := v.Validate(s2).(Errors)
+	assertFalse(ok, "case2", t)
+
+	// the rule is only registered to v
+	err3 := Default.Validate(s)
+	_, ok = err3.(Errors)
+	assertTrue(err3 != nil && !ok && strings.Contains(err3.Error(), "even"), "case3", t)
+}
+
+func TestValidatorMessages(t *testing.T) {
+	v := New()
+	v.SetMessages(map[string]string{
+		"required": "{field}不能为空",
+	})
+
+	errs := v.Validate(&address{}).(Errors)
+	assertTrue(errs[0].Message == "City不能为空", "case1", t)
+
+	s := struct {
+		Name string `validate:"min=3"`
+	}{"ab"}
+	errs2 := v.Validate(s).(Errors)
+	assertTrue(errs2[0].Message == "Name must be at least 3", "case2", t)
+}
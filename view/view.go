@@ -0,0 +1,224 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+// Package view is the HTML template engine for light framework.
+//
+// The templates are loaded from a directory, such as:
+//
+//	views/
+//		layouts/main.html     layout, {{block "content" .}}{{end}} is replaced by pages
+//		partials/header.html  partial, used by {{template "partials/header" .}}
+//		users/show.html       page, {{define "content"}}...{{end}}
+//
+// A template is named by its path relative to the directory, without the
+// file extension, such as "users/show". Every page can use all partials and
+// is rendered in a layout.
+//
+// The url of named routes can be built in templates by the Mux.URL func:
+//
+//	engine := view.New("views")
+//	engine.Funcs(template.FuncMap{"url": mux.URL})
+//	mux.View = engine
+//
+//	<a href="{{url "user" "id" .Id}}">{{.Name}}</a>
+package view
+
+import (
+	"github.com/arging/utils/errors"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+const (
+	layoutDir  = "layouts"  // Directory for layouts
+	partialDir = "partials" // Directory for partials
+)
+
+// Engine loads and renders the HTML templates.
+type Engine struct {
+	// The default layout name for pages, such as "main".
+	// If it is empty, the page is rendered without layout.
+	Layout string
+
+	// Reparse the templates for each rendering, it is useful in development.
+	Reload bool
+
+	fsys  fs.FS
+	ext   string
+	funcs template.FuncMap
+
+	mu        sync.RWMutex
+	files     map[string]string             // name -> file path
+	templates map[string]*template.Template // layout|page -> template
+}
+
+// Create the engine for the template directory, the template file
+// extension is ".html".
+func New(dir string) *Engine {
+	return NewFS(os.DirFS(dir), ".html")
+}
+
+// Create the engine for the template file system, such as embed.FS,
+// the template files are filtered by the file extension.
+func NewFS(fsys fs.FS, ext string) *Engine {
+	return &Engine{fsys: fsys, ext: ext, funcs: make(template.FuncMap)}
+}
+
+// Register the template funcs, it must be called before Load.
+func (e *Engine) Funcs(funcs template.FuncMap) *Engine {
+	for name, fn := range funcs {
+		e.funcs[name] = fn
+	}
+	return e
+}
+
+// Load the templates, the pages are parsed to check errors.
+func (e *Engine) Load() error {
+	if err := e.scan(); err != nil {
+		return err
+	}
+
+	e.mu.RLock()
+	files := e.files
+	e.mu.RUnlock()
+	for name := range files {
+		if isPage(name) {
+			if _, err := e.template(e.Layout, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Find the template files, and clear the parsed templates.
+func (e *Engine) scan() error {
+	files := make(map[string]string)
+	err := fs.WalkDir(e.fsys, ".", func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(file, e.ext) {
+			files[strings.TrimSuffix(file, e.ext)] = file
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "view load error.")
+	}
+
+	e.mu.Lock()
+	e.files = files
+	e.templates = make(map[string]*template.Template)
+	e.mu.Unlock()
+	return nil
+}
+
+// Render the page in the default layout.
+func (e *Engine) Render(w io.Writer, name string, data interface{}) error {
+	return e.RenderLayout(w, e.Layout, name, data)
+}
+
+// Render the page in the layout, the page is rendered without layout
+// when the layout is empty.
+func (e *Engine) RenderLayout(w io.Writer, layout string, name string, data interface{}) error {
+	if e.Reload {
+		if err := e.scan(); err != nil {
+			return err
+		}
+	}
+
+	t, err := e.template(layout, name)
+	if err != nil {
+		return err
+	}
+
+	entry := name
+	if layout != "" {
+		entry = layoutName(layout)
+	}
+	if err := t.ExecuteTemplate(w, entry, data); err != nil {
+		return errors.Wrapf(err, "view render error: %s.", name)
+	}
+	return nil
+}
+
+// Get the parsed template of the page in the layout.
+// The partials are parsed first, then the layout and the page,
+// so the blocks in the layout are replaced by the page.
+func (e *Engine) template(layout string, name string) (*template.Template, error) {
+	key := layout + "|" + name
+	e.mu.RLock()
+	t, ok := e.templates[key]
+	files := e.files
+	e.mu.RUnlock()
+	if ok {
+		return t, nil
+	}
+	if files == nil {
+		return nil, errors.Newf("view render error, templates are not loaded: %s.", name)
+	}
+
+	page, ok := files[name]
+	if !ok || !isPage(name) {
+		return nil, errors.Newf("view render error, no template: %s.", name)
+	}
+
+	t = template.New(key).Funcs(e.funcs)
+	for partial, file := range files {
+		if strings.HasPrefix(partial, partialDir+"/") {
+			if err := e.parse(t, partial, file); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if layout != "" {
+		file, ok := files[layoutName(layout)]
+		if !ok {
+			return nil, errors.Newf("view render error, no layout: %s.", layout)
+		}
+		if err := e.parse(t, layoutName(layout), file); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := e.parse(t, name, page); err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	e.templates[key] = t
+	e.mu.Unlock()
+	return t, nil
+}
+
+// Parse the template file as the named template of t.
+func (e *Engine) parse(t *template.Template, name string, file string) error {
+	data, err := fs.ReadFile(e.fsys, file)
+	if err != nil {
+		return errors.Wrapf(err, "view parse error: %s.", file)
+	}
+
+	if _, err := t.New(name).Parse(string(data)); err != nil {
+		return errors.Wrapf(err, "view parse error: %s.", file)
+	}
+	return nil
+}
+
+// Is the template a page, but not a layout or partial.
+func isPage(name string) bool {
+	dir := strings.SplitN(name, "/", 2)[0]
+	return dir != layoutDir && dir != partialDir || !strings.Contains(name, "/")
+}
+
+// Get the template name of the layout.
+func layoutName(layout string) string {
+	return path.Join(layoutDir, layout)
+}
@@ -0,0 +1,90 @@
+// Copyright 2014 li. All rights reserved.
+// Use of this source code is governed by a MIT/X11
+// license that can be found in the LICENSE file.
+
+package view
+
+import (
+	"bytes"
+	"github.com/arging/utils/errors"
+	"html/template"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func assertTrue(rs bool, msg string, t *testing.T) {
+	if !rs {
+		// Track the test error source.
+		t.Error(errors.New(msg))
+	}
+}
+func assertFalse(rs bool, msg string, t *testing.T) {
+	assertTrue(!rs, msg, t)
+}
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"layouts/main.html":    {Data: []byte(`<main>{{template "partials/header" .}}{{block "content" .}}default{{end}}</main>`)},
+		"layouts/plain.html":   {Data: []byte(`<div>{{block "content" .}}{{end}}</div>`)},
+		"partials/header.html": {Data: []byte(`<h1>{{.Title | upper}}</h1>`)},
+		"users/show.html":      {Data: []byte(`{{define "content"}}<p>{{.Name}}</p>{{end}}`)},
+		"home.html":            {Data: []byte(`<p>{{.Name}}</p>`)},
+		"readme.txt":           {Data: []byte(`{{`)},
+	}
+}
+
+func testEngine(fsys fstest.MapFS) *Engine {
+	e := NewFS(fsys, ".html")
+	e.Funcs(template.FuncMap{"upper": strings.ToUpper})
+	return e
+}
+
+func TestEngineRender(t *testing.T) {
+	e := testEngine(testFS())
+	e.Layout = "main"
+	assertTrue(e.Load() == nil, "case0", t)
+
+	data := map[string]string{"Title": "user", "Name": "<li>"}
+	var buf1 bytes.Buffer
+	err1 := e.Render(&buf1, "users/show", data)
+	assertTrue(err1 == nil, "case1", t)
+	assertTrue(buf1.String() == "<main><h1>USER</h1><p>&lt;li&gt;</p></main>", "case1", t)
+
+	var buf2 bytes.Buffer
+	e.RenderLayout(&buf2, "plain", "users/show", data)
+	assertTrue(buf2.String() == "<div><p>&lt;li&gt;</p></div>", "case2", t)
+
+	var buf3 bytes.Buffer
+	e.RenderLayout(&buf3, "", "home", data)
+	assertTrue(buf3.String() == "<p>&lt;li&gt;</p>", "case3", t)
+
+	// exceptional case
+	assertTrue(e.Render(&bytes.Buffer{}, "none", data) != nil, "case4", t)
+	assertTrue(e.Render(&bytes.Buffer{}, "partials/header", data) != nil, "case4", t)
+	assertTrue(e.RenderLayout(&bytes.Buffer{}, "none", "home", data) != nil, "case4", t)
+}
+
+func TestEngineLoadError(t *testing.T) {
+	fsys := testFS()
+	fsys["bad.html"] = &fstest.MapFile{Data: []byte(`{{.Name`)}
+	assertTrue(testEngine(fsys).Load() != nil, "case1", t)
+
+	assertTrue(testEngine(testFS()).Render(&bytes.Buffer{}, "home", nil) != nil, "case2", t)
+}
+
+func TestEngineReload(t *testing.T) {
+	fsys := testFS()
+	e := testEngine(fsys)
+	e.Load()
+
+	fsys["home.html"] = &fstest.MapFile{Data: []byte(`<b>{{.}}</b>`)}
+	var buf1 bytes.Buffer
+	e.Render(&buf1, "home", "li")
+	assertFalse(strings.Contains(buf1.String(), "<b>"), "case1", t)
+
+	e.Reload = true
+	var buf2 bytes.Buffer
+	e.Render(&buf2, "home", "li")
+	assertTrue(buf2.String() == "<b>li</b>", "case2", t)
+}